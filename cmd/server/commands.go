@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// runCommand execute soroban sub command from remaining command line arguments
+func runCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing command")
+	}
+
+	switch args[0] {
+	case "confidential":
+		return confidentialCommand(args[1:])
+
+	case "sign":
+		return signCommand(args[1:])
+
+	case "verify":
+		return verifyCommand(args[1:])
+
+	case "token":
+		return tokenCommand(args[1:])
+
+	case "secret":
+		return secretCommand(args[1:])
+
+	default:
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"code.samourai.io/wallet/samourai-soroban/confidential"
+)
+
+const confidentialUsage = "usage: soroban confidential check <file> | soroban confidential sign -algorithm <algorithm> -keyFile <file> -version <version> <file>"
+
+func confidentialCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New(confidentialUsage)
+	}
+
+	switch args[0] {
+	case "check":
+		if len(args) != 2 {
+			return errors.New(confidentialUsage)
+		}
+		return confidentialCheck(args[1])
+
+	case "sign":
+		return confidentialSign(args[1:])
+
+	default:
+		return errors.New(confidentialUsage)
+	}
+}
+
+// confidentialCheck validate confidential config file
+func confidentialCheck(filename string) error {
+	config, err := confidential.ConfigLoad(filename)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	fmt.Printf("%s: OK (%d confidential entries)\n", filename, len(config.Confidential))
+	return nil
+}
+
+// confidentialSign print admin ruleset signed from confidential config file
+func confidentialSign(args []string) error {
+	flags := flag.NewFlagSet("confidential sign", flag.ContinueOnError)
+	algorithm := flags.String("algorithm", "", fmt.Sprintf("Admin key algorithm (%s)", strings.Join(confidential.Algorithms(), ", ")))
+	keyFile := flags.String("keyFile", "", fmt.Sprintf("File containing admin private key, - for stdin (default %s)", SignKeyEnv))
+	version := flags.Uint64("version", 0, "Ruleset version, must be greater than current version")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(confidentialUsage)
+	}
+
+	if len(*algorithm) == 0 || *version == 0 {
+		return errors.New("algorithm and version are required")
+	}
+	privateKey, err := readPrivateKey(*keyFile)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	ruleset, err := confidential.SignRuleset(*algorithm, privateKey, *version, data)
+	if err != nil {
+		return err
+	}
+
+	data, err = json.MarshalIndent(&ruleset, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
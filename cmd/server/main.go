@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"math/rand"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	soroban "code.samourai.io/wallet/samourai-soroban"
+	"code.samourai.io/wallet/samourai-soroban/p2p"
 	"code.samourai.io/wallet/samourai-soroban/server"
 
 	"code.samourai.io/wallet/samourai-soroban/services"
@@ -22,8 +24,9 @@ import (
 var (
 	options soroban.Options = soroban.DefaultOptions
 
-	prefix string
-	export string
+	prefix    string
+	export    string
+	secretsFd int
 )
 
 func init() {
@@ -40,8 +43,12 @@ func init() {
 	// Server
 	flag.StringVar(&options.Soroban.Config, "config", options.Soroban.Config, "Yaml configuration file for soroban")
 	flag.StringVar(&options.Soroban.Confidential, "confidential", options.Soroban.Confidential, "Yaml configuration file for confidential keys")
+	flag.StringVar(&options.Soroban.ConfidentialAdmin, "confidentialAdmin", options.Soroban.ConfidentialAdmin, "Admin public key for confidential rulesets distributed over p2p")
+	flag.StringVar(&options.Soroban.ConfidentialAdminAlgorithm, "confidentialAdminAlgorithm", options.Soroban.ConfidentialAdminAlgorithm, "Admin public key algorithm")
+	flag.StringVar(&options.Soroban.ConfidentialRuleset, "confidentialRuleset", options.Soroban.ConfidentialRuleset, "Signed confidential ruleset file (json)")
 	flag.StringVar(&options.Soroban.Domain, "domain", options.Soroban.Domain, "Directory Domain")
 	flag.StringVar(&options.Soroban.Seed, "seed", options.Soroban.Seed, "Onion private key seed")
+	flag.StringVar(&options.Soroban.SeedFile, "seedFile", options.Soroban.SeedFile, "File containing onion private key seed, encrypted if SOROBAN_SEED_PASSPHRASE is set")
 
 	flag.BoolVar(&options.Soroban.WithTor, "withTor", options.Soroban.WithTor, "Hidden service enabled (default false)")
 	flag.StringVar(&options.Soroban.Hostname, "hostname", options.Soroban.Hostname, "server address (default localhost)")
@@ -49,17 +56,31 @@ func init() {
 
 	flag.StringVar(&options.Soroban.DirectoryType, "directoryType", options.Soroban.DirectoryType, "Directory Type (default, redis, memory)")
 
-	flag.StringVar(&options.P2P.Seed, "p2pSeed", options.P2P.Seed, "P2P Onion private key seed")
-	flag.StringVar(&options.P2P.Bootstrap, "p2pBootstrap", options.P2P.Bootstrap, "P2P bootstrap")
+	flag.StringVar(&options.P2P.Seed, "p2pSeed", options.P2P.Seed, "P2P Onion private key seed (auto for ephemeral identity)")
+	flag.StringVar(&options.P2P.SeedFile, "p2pSeedFile", options.P2P.SeedFile, "File containing P2P seed, encrypted if SOROBAN_SEED_PASSPHRASE is set")
+	flag.StringVar(&options.P2P.KeyFile, "p2pKeyFile", options.P2P.KeyFile, "P2P identity key file, created if missing")
+	flag.Var(&options.P2P.Bootstrap, "p2pBootstrap", "P2P bootstrap (comma separated)")
 	flag.StringVar(&options.P2P.Hostname, "p2pHostname", options.P2P.Hostname, "P2P Hostname")
 	flag.IntVar(&options.P2P.ListenPort, "p2pListenPort", options.P2P.ListenPort, "P2P Listen Port")
 	flag.StringVar(&options.P2P.Room, "p2pRoom", options.P2P.Room, "P2P Room")
+	flag.IntVar(&options.P2P.MinPeers, "p2pMinPeers", options.P2P.MinPeers, "P2P mesh peers to wait for before publishing writes to default room (default 0, publish immediately)")
+	flag.Var(jsonFlag{&options.P2P.Rooms}, "p2pRooms", "P2P additional rooms (json)")
+	flag.Var(jsonFlag{&options.P2P.Replication}, "p2pReplication", "P2P replication policy per prefix: local, room or room name (json)")
+	flag.StringVar(&options.P2P.BanList, "p2pBanList", options.P2P.BanList, "P2P ban list file, managed by admin json-rpc")
+	flag.StringVar(&options.P2P.Security, "p2pSecurity", options.P2P.Security, "P2P security transports in preference order (noise, tls, plaintext, comma separated)")
+	flag.StringVar(&options.P2P.Encoding, "p2pEncoding", options.P2P.Encoding, "P2P message encoding (json, binary, auto: binary when all room peers support it)")
+	flag.IntVar(&options.P2P.ExitTimeout, "p2pExitTimeout", options.P2P.ExitTimeout, "P2P minutes without heartbeat before exiting (default 0, never exit)")
+	flag.BoolVar(&options.P2P.LegacyHeartbeat, "p2pLegacyHeartbeat", options.P2P.LegacyHeartbeat, "P2P send heartbeats as p2p.heartbeat entries, for older peers (default true, until all peers support liveness protocol)")
+	flag.Var(jsonFlag{&options.P2P.Score}, "p2pScore", "P2P peer score parameters (json)")
+	flag.Var(jsonFlag{&options.P2P.Queue}, "p2pQueue", "P2P inbound queue size, workers and drop policy (json)")
+	flag.StringVar(&options.P2P.PeerBook, "p2pPeerBook", options.P2P.PeerBook, "P2P peer book file, to reconnect to known peers on restart")
 
 	flag.StringVar(&options.IPC.Subject, "ipcSubject", options.IPC.Subject, "IPC communication subject")
 	flag.IntVar(&options.IPC.ChildID, "ipcChildID", options.IPC.ChildID, "IPC child ID")
 	flag.IntVar(&options.IPC.ChildProcessCount, "ipcChildProcessCount", options.IPC.ChildProcessCount, "Spawn child process")
 	flag.StringVar(&options.IPC.NatsHost, "ipcNatsHost", options.IPC.NatsHost, "IPC NATS host")
 	flag.IntVar(&options.IPC.NatsPort, "ipcNatsPort", options.IPC.NatsPort, "IPC nats port")
+	flag.IntVar(&secretsFd, "secretsFd", 0, "File descriptor of secrets sent by IPC server")
 
 	flag.Parse()
 
@@ -74,6 +95,18 @@ func init() {
 	}
 	log.SetLevel(level)
 
+	// secrets from files, environment or IPC server
+	if err := options.LoadSecrets(); err != nil {
+		log.WithError(err).Fatal("Failed to load secrets")
+	}
+	if secretsFd > 0 {
+		secrets, err := soroban.ReadSecrets(secretsFd)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to read secrets")
+		}
+		options.P2P.Seed = secrets.P2PSeed
+	}
+
 	logOutput := os.Stderr
 	if len(options.LogFile) > 0 && options.LogFile != "-" {
 		log.SetFormatter(&log.JSONFormatter{})
@@ -94,6 +127,14 @@ func init() {
 }
 
 func main() {
+	// run sub command & exit
+	if args := flag.Args(); len(args) > 0 {
+		if err := runCommand(args); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// export seed & exit
 	if len(export) > 0 && len(options.Soroban.Seed) > 0 {
 		data, err := server.ExportHiddenServiceSecret(options.Soroban.Seed)
@@ -119,6 +160,11 @@ func run() error {
 	}
 	prefix = strings.Trim(prefix, " ")
 
+	// version reported to p2p peers
+	if len(Version) > 0 {
+		p2p.Version = Version
+	}
+
 	ctx := context.Background()
 	ctx = soroban.WithTorContext(ctx)
 
@@ -159,6 +205,23 @@ func run() error {
 	return nil
 }
 
+// jsonFlag parse structured options from json
+type jsonFlag struct {
+	value interface{}
+}
+
+func (p jsonFlag) String() string {
+	if p.value == nil {
+		return ""
+	}
+	data, _ := json.Marshal(p.value)
+	return string(data)
+}
+
+func (p jsonFlag) Set(value string) error {
+	return json.Unmarshal([]byte(value), p.value)
+}
+
 func WaitForExit(ctx context.Context) {
 	sigs := make(chan os.Signal, 1)
 	done := make(chan bool, 1)
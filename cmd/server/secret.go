@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	soroban "code.samourai.io/wallet/samourai-soroban"
+)
+
+const secretUsage = "usage: soroban secret encrypt <file>"
+
+func secretCommand(args []string) error {
+	if len(args) != 2 || args[0] != "encrypt" {
+		return errors.New(secretUsage)
+	}
+	return secretEncrypt(args[1])
+}
+
+// secretEncrypt print seed file encrypted with passphrase from environment
+func secretEncrypt(filename string) error {
+	passphrase := os.Getenv(soroban.SeedPassphraseEnv)
+	if len(passphrase) == 0 {
+		return fmt.Errorf("%s not set", soroban.SeedPassphraseEnv)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := soroban.EncryptSecret(strings.TrimSpace(string(data)), passphrase)
+	if err != nil {
+		return err
+	}
+	fmt.Println(encrypted)
+	return nil
+}
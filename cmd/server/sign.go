@@ -0,0 +1,322 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"code.samourai.io/wallet/samourai-soroban/confidential"
+	"code.samourai.io/wallet/samourai-soroban/services"
+)
+
+// SignKeyEnv is the environment variable for the private key of sign commands, when keyFile is not set
+const SignKeyEnv = "SOROBAN_SIGN_KEY"
+
+// rpcRequest is a json-rpc request ready to post on /rpc
+type rpcRequest struct {
+	JsonRPC string            `json:"jsonrpc"`
+	ID      int               `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+func rpcMethod(operation string) (string, error) {
+	switch operation {
+	case confidential.OperationList:
+		return "directory.List", nil
+	case confidential.OperationAdd:
+		return "directory.Add", nil
+	case confidential.OperationRemove:
+		return "directory.Remove", nil
+	case confidential.OperationBan:
+		return "p2p.Ban", nil
+	case confidential.OperationUnban:
+		return "p2p.Unban", nil
+	default:
+		return "", fmt.Errorf("unknown operation: %s", operation)
+	}
+}
+
+func rpcOperation(method string) (string, error) {
+	switch method {
+	case "directory.List":
+		return confidential.OperationList, nil
+	case "directory.Add":
+		return confidential.OperationAdd, nil
+	case "directory.Remove":
+		return confidential.OperationRemove, nil
+	case "p2p.Ban":
+		return confidential.OperationBan, nil
+	case "p2p.Unban":
+		return confidential.OperationUnban, nil
+	case "p2p.BanList":
+		return confidential.OperationList, nil
+	default:
+		return "", fmt.Errorf("unknown method: %s", method)
+	}
+}
+
+func newNonce() (string, error) {
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(nonce[:]), nil
+}
+
+func readKeyFile(filename string) (string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readPrivateKey read private key from file, stdin if filename is `-`, or environment.
+// Private keys are never read from command line, which is visible to other users.
+func readPrivateKey(filename string) (string, error) {
+	var key string
+	switch filename {
+	case "":
+		key = strings.TrimSpace(os.Getenv(SignKeyEnv))
+	case "-":
+		data, err := io.ReadAll(io.LimitReader(os.Stdin, 4096))
+		if err != nil {
+			return "", err
+		}
+		key = strings.TrimSpace(string(data))
+	default:
+		var err error
+		key, err = readKeyFile(filename)
+		if err != nil {
+			return "", err
+		}
+	}
+	if len(key) == 0 {
+		return "", fmt.Errorf("private key required, from keyFile or %s", SignKeyEnv)
+	}
+	return key, nil
+}
+
+// readTokens read a delegation token chain (json array) from file
+func readTokens(filename string) ([]confidential.Token, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var tokens []confidential.Token
+	err = json.Unmarshal(data, &tokens)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("empty token chain")
+	}
+	return tokens, nil
+}
+
+// signCommand print a signed json-rpc request for list, add or remove
+func signCommand(args []string) error {
+	flags := flag.NewFlagSet("sign", flag.ContinueOnError)
+	algorithm := flags.String("algorithm", "", fmt.Sprintf("Signature algorithm (%s)", strings.Join(confidential.Algorithms(), ", ")))
+	keyFile := flags.String("keyFile", "", fmt.Sprintf("File containing private key (hex for nacl, WIF otherwise), - for stdin (default %s)", SignKeyEnv))
+	tokensFile := flags.String("tokens", "", "File containing delegation token chain (add, remove), key is the last delegated key")
+	operation := flags.String("operation", confidential.OperationAdd, "Operation (list, add, remove, ban, unban)")
+	name := flags.String("name", "", "Directory name")
+	entry := flags.String("entry", "", "Directory entry (add, remove)")
+	mode := flags.String("mode", "", "TTL mode (add, remove)")
+	domain := flags.String("domain", options.Soroban.Domain, "Soroban domain")
+	version := flags.Int("version", confidential.SignatureV2, "Signature message version")
+	nonce := flags.String("nonce", "", "Message nonce (default random)")
+	timestamp := flags.Int64("timestamp", 0, "Message timestamp in nanoseconds (default now)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if len(*algorithm) == 0 || len(*name) == 0 {
+		return errors.New("algorithm and name are required")
+	}
+	privateKey, err := readPrivateKey(*keyFile)
+	if err != nil {
+		return err
+	}
+	alg, err := confidential.GetAlgorithm(*algorithm)
+	if err != nil {
+		return err
+	}
+	publicKey, err := alg.PublicKey(privateKey)
+	if err != nil {
+		return err
+	}
+	if *timestamp == 0 {
+		*timestamp = time.Now().UTC().UnixNano()
+	}
+	if len(*nonce) == 0 && confidential.SignatureVersion(*version) >= confidential.SignatureV2 {
+		*nonce, err = newNonce()
+		if err != nil {
+			return err
+		}
+	}
+
+	method, err := rpcMethod(*operation)
+	if err != nil {
+		return err
+	}
+	if *operation == confidential.OperationList && *name == services.AdminBanListName {
+		method = "p2p.BanList"
+	}
+
+	var tokens []confidential.Token
+	if len(*tokensFile) > 0 {
+		if *operation != confidential.OperationAdd && *operation != confidential.OperationRemove {
+			return errors.New("tokens are only allowed for add and remove")
+		}
+		tokens, err = readTokens(*tokensFile)
+		if err != nil {
+			return err
+		}
+		if last := tokens[len(tokens)-1]; last.PublicKey != publicKey || last.Algorithm != *algorithm {
+			return errors.New("key is not the last delegated key of token chain")
+		}
+	}
+
+	var params interface{}
+	switch *operation {
+	case confidential.OperationList:
+		p := services.DirectoryEntries{
+			Name:      *name,
+			PublicKey: publicKey,
+			Algorithm: *algorithm,
+			Timestamp: *timestamp,
+			Version:   *version,
+			Nonce:     *nonce,
+		}
+		message, err := p.SignatureMessage(*domain)
+		if err != nil {
+			return err
+		}
+		p.Signature, err = alg.Sign(privateKey, message)
+		if err != nil {
+			return err
+		}
+		params = p
+
+	default:
+		p := services.DirectoryEntry{
+			Name:      *name,
+			Entry:     *entry,
+			Mode:      *mode,
+			PublicKey: publicKey,
+			Algorithm: *algorithm,
+			Timestamp: *timestamp,
+			Version:   *version,
+			Nonce:     *nonce,
+			Tokens:    tokens,
+		}
+		message, err := p.SignatureMessage(*domain, *operation)
+		if err != nil {
+			return err
+		}
+		p.Signature, err = alg.Sign(privateKey, message)
+		if err != nil {
+			return err
+		}
+		params = p
+	}
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	request := rpcRequest{
+		JsonRPC: "2.0",
+		ID:      1,
+		Method:  method,
+		Params:  []json.RawMessage{data},
+	}
+	data, err = json.MarshalIndent(&request, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// verifyCommand check signature of a json-rpc request read from file or stdin
+func verifyCommand(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	algorithm := flags.String("algorithm", "", fmt.Sprintf("Rule key algorithm (%s)", strings.Join(confidential.Algorithms(), ", ")))
+	ruleKey := flags.String("rule-key", "", "Rule public key, from confidential config")
+	domain := flags.String("domain", options.Soroban.Domain, "Soroban domain")
+	allowV1 := flags.Bool("allowv1", true, "Accept deprecated v1 signature messages, as server does by default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	// request keys are untrusted, the rule key is always given by caller
+	if len(*algorithm) == 0 || len(*ruleKey) == 0 {
+		return errors.New("algorithm and rule-key are required")
+	}
+
+	var reader io.Reader = os.Stdin
+	if filename := flags.Arg(0); len(filename) > 0 && filename != "-" {
+		file, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	var request rpcRequest
+	if err := json.NewDecoder(reader).Decode(&request); err != nil {
+		return err
+	}
+	if len(request.Params) != 1 {
+		return errors.New("invalid json-rpc params")
+	}
+	operation, err := rpcOperation(request.Method)
+	if err != nil {
+		return err
+	}
+
+	confidential.SetConfig(confidential.SorobanConfig{AllowSignatureV1: allowV1})
+
+	switch operation {
+	case confidential.OperationList:
+		var p services.DirectoryEntries
+		if err := json.Unmarshal(request.Params[0], &p); err != nil {
+			return err
+		}
+		err = p.VerifySignature(verifyRule(p.Name, *algorithm, *ruleKey), *domain)
+
+	default:
+		var p services.DirectoryEntry
+		if err := json.Unmarshal(request.Params[0], &p); err != nil {
+			return err
+		}
+		err = p.VerifySignature(verifyRule(p.Name, *algorithm, *ruleKey), *domain, operation)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Signature verified")
+	return nil
+}
+
+// verifyRule return the confidential rule of name for rule key
+func verifyRule(name, algorithm, publicKey string) confidential.ConfidentialEntry {
+	return confidential.ConfidentialEntry{
+		Prefix:    name,
+		Algorithm: algorithm,
+		PublicKey: publicKey,
+		ReadOnly:  true,
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"code.samourai.io/wallet/samourai-soroban/confidential"
+)
+
+const tokenUsage = "usage: soroban token sign -algorithm <algorithm> -keyFile <file> -delegate <publickey> -delegateAlgorithm <algorithm> -prefixes <prefixes> | soroban token verify -algorithm <algorithm> -rule-key <publickey> -name <name> -operation <operation> <file>"
+
+func tokenCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New(tokenUsage)
+	}
+
+	switch args[0] {
+	case "sign":
+		return tokenSign(args[1:])
+
+	case "verify":
+		return tokenVerify(args[1:])
+
+	default:
+		return errors.New(tokenUsage)
+	}
+}
+
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// tokenSign print a delegation token chain, issued by key to delegate.
+// The token is appended to chain when set, key must then be the last delegated key.
+func tokenSign(args []string) error {
+	flags := flag.NewFlagSet("token sign", flag.ContinueOnError)
+	algorithm := flags.String("algorithm", "", fmt.Sprintf("Issuer key algorithm (%s)", strings.Join(confidential.Algorithms(), ", ")))
+	keyFile := flags.String("keyFile", "", fmt.Sprintf("File containing issuer private key, - for stdin (default %s)", SignKeyEnv))
+	chainFile := flags.String("chain", "", "File containing token chain to extend")
+	delegate := flags.String("delegate", "", "Delegated public key")
+	delegateAlgorithm := flags.String("delegateAlgorithm", "", "Delegated key algorithm (default issuer algorithm)")
+	prefixes := flags.String("prefixes", "", "Allowed directory prefixes (comma separated)")
+	operations := flags.String("operations", strings.Join([]string{confidential.OperationAdd, confidential.OperationRemove}, ","), "Allowed operations (comma separated)")
+	ttl := flags.Duration("ttl", time.Hour, "Token validity")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if len(*algorithm) == 0 || len(*delegate) == 0 || len(*prefixes) == 0 || len(*operations) == 0 {
+		return errors.New("algorithm, delegate, prefixes and operations are required")
+	}
+	if *ttl <= 0 {
+		return errors.New("invalid ttl")
+	}
+	if len(*delegateAlgorithm) == 0 {
+		*delegateAlgorithm = *algorithm
+	}
+	delegateAlg, err := confidential.GetAlgorithm(*delegateAlgorithm)
+	if err != nil {
+		return err
+	}
+	err = delegateAlg.ValidatePublicKey(*delegate)
+	if err != nil {
+		return err
+	}
+
+	privateKey, err := readPrivateKey(*keyFile)
+	if err != nil {
+		return err
+	}
+	alg, err := confidential.GetAlgorithm(*algorithm)
+	if err != nil {
+		return err
+	}
+	issuer, err := alg.PublicKey(privateKey)
+	if err != nil {
+		return err
+	}
+
+	var chain []confidential.Token
+	if len(*chainFile) > 0 {
+		chain, err = readTokens(*chainFile)
+		if err != nil {
+			return err
+		}
+		if last := chain[len(chain)-1]; last.PublicKey != issuer || last.Algorithm != *algorithm {
+			return errors.New("key is not the last delegated key of token chain")
+		}
+	}
+	if len(chain) >= confidential.MaxTokenChain {
+		return errors.New("token chain too long")
+	}
+
+	token := confidential.Token{
+		Issuer:     issuer,
+		Prefixes:   splitList(*prefixes),
+		Operations: splitList(*operations),
+		Algorithm:  *delegateAlgorithm,
+		PublicKey:  *delegate,
+		Expiry:     time.Now().Add(*ttl).Unix(),
+	}
+	token.Signature, err = alg.Sign(privateKey, token.Message())
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(append(chain, token), "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// tokenVerify check a token chain read from file or stdin allows operation on name for rule key
+func tokenVerify(args []string) error {
+	flags := flag.NewFlagSet("token verify", flag.ContinueOnError)
+	algorithm := flags.String("algorithm", "", fmt.Sprintf("Rule key algorithm (%s)", strings.Join(confidential.Algorithms(), ", ")))
+	ruleKey := flags.String("rule-key", "", "Rule public key, from confidential config")
+	name := flags.String("name", "", "Directory name")
+	operation := flags.String("operation", confidential.OperationAdd, "Operation (list, add, remove)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if len(*algorithm) == 0 || len(*ruleKey) == 0 || len(*name) == 0 {
+		return errors.New("algorithm, rule-key and name are required")
+	}
+
+	var reader io.Reader = os.Stdin
+	if filename := flags.Arg(0); len(filename) > 0 && filename != "-" {
+		file, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	var tokens []confidential.Token
+	if err := json.NewDecoder(reader).Decode(&tokens); err != nil {
+		return err
+	}
+
+	delegate, err := confidential.VerifyDelegation(verifyRule(*name, *algorithm, *ruleKey), tokens, *name, *operation, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Token chain verified, delegated to %s (%s)\n", delegate.PublicKey, delegate.Algorithm)
+	return nil
+}
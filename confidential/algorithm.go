@@ -0,0 +1,73 @@
+package confidential
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Algorithm sign & verify messages for a confidential key type
+type Algorithm interface {
+	// ValidatePublicKey check public key encoding
+	ValidatePublicKey(publicKey string) error
+	// PublicKey return public key from private key
+	PublicKey(privateKey string) (string, error)
+	// Sign message with private key
+	Sign(privateKey, message string) (string, error)
+	// Verify message signature with public key
+	Verify(publicKey, message, signature string) error
+}
+
+var (
+	algorithms       = make(map[string]Algorithm)
+	algorithmsLocker sync.RWMutex
+)
+
+func init() {
+	RegisterAlgorithm(AlgorithmNacl, naclAlgorithm{})
+	RegisterAlgorithm(AlgorithmEcdsa, ecdsaAlgorithm{})
+	RegisterAlgorithm(AlgorithmTestnet3, bitcoinAlgorithm{params: testnet3Params})
+	RegisterAlgorithm(AlgorithmMainnet, bitcoinAlgorithm{params: mainnetParams})
+}
+
+// RegisterAlgorithm add or replace a signature algorithm
+func RegisterAlgorithm(name string, algorithm Algorithm) {
+	algorithmsLocker.Lock()
+	defer algorithmsLocker.Unlock()
+
+	algorithms[name] = algorithm
+}
+
+// GetAlgorithm return registered algorithm from name
+func GetAlgorithm(name string) (Algorithm, error) {
+	algorithmsLocker.RLock()
+	defer algorithmsLocker.RUnlock()
+
+	if algorithm, ok := algorithms[name]; ok {
+		return algorithm, nil
+	}
+	// algorithm may be known by newer peers
+	return nil, Mismatch(errors.New("unknown signature algorithm"))
+}
+
+// Algorithms return sorted names of registered algorithms
+func Algorithms() []string {
+	algorithmsLocker.RLock()
+	defer algorithmsLocker.RUnlock()
+
+	var result []string
+	for name := range algorithms {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// SignMessage sign message with private key for algorithm
+func SignMessage(algorithm, privateKey, message string) (string, error) {
+	alg, err := GetAlgorithm(algorithm)
+	if err != nil {
+		return "", err
+	}
+	return alg.Sign(privateKey, message)
+}
@@ -1,13 +1,17 @@
 package confidential
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"gopkg.in/yaml.v2"
@@ -22,21 +26,38 @@ const (
 	AlgorithmMainnet  = "mainnet"
 )
 
+// RateLimit allow Rate writes per second, with Burst writes at once
+type RateLimit struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+// RateLimitEntry limits writes per confidential rule (all names matching prefix), per directory name for unsigned writes
+// and per signing public key
+type RateLimitEntry struct {
+	Key       RateLimit `yaml:"key"`
+	Name      RateLimit `yaml:"name"`
+	PublicKey RateLimit `yaml:"publickey"`
+}
+
 type ConfidentialEntry struct {
-	Prefix       string `yaml:"prefix"`
-	Algorithm    string `yaml:"algorithm"`
-	PublicKey    string `yaml:"publickey"`
-	Confidential bool   `yaml:"confidential"`
-	ReadOnly     bool   `yaml:"readonly"`
+	Prefix       string         `yaml:"prefix"`
+	Algorithm    string         `yaml:"algorithm"`
+	PublicKey    string         `yaml:"publickey"`
+	Confidential bool           `yaml:"confidential"`
+	ReadOnly     bool           `yaml:"readonly"`
+	RateLimit    RateLimitEntry `yaml:"ratelimit"`
 }
 
 type SorobanConfig struct {
-	Confidential []ConfidentialEntry `yaml:"confidential"`
+	// AllowSignatureV1 keep legacy signature messages accepted during migration, accepted if not set
+	AllowSignatureV1 *bool               `yaml:"allowsignaturev1,omitempty"`
+	Confidential     []ConfidentialEntry `yaml:"confidential"`
 }
 
 var (
-	DefaultSorobanConfig SorobanConfig
-
+	sorobanConfig       SorobanConfig
+	sorobanConfigMutex  sync.RWMutex
 	sorobanRegexpMap    map[string]*regexp.Regexp
 	sorobanConfigLocker sync.Mutex
 )
@@ -45,21 +66,117 @@ func init() {
 	sorobanRegexpMap = make(map[string]*regexp.Regexp)
 }
 
+// Config return current confidential config
+func Config() SorobanConfig {
+	sorobanConfigMutex.RLock()
+	defer sorobanConfigMutex.RUnlock()
+
+	return sorobanConfig
+}
+
+// SetConfig replace current confidential config
+func SetConfig(config SorobanConfig) {
+	sorobanConfigMutex.Lock()
+	defer sorobanConfigMutex.Unlock()
+
+	sorobanConfig = config
+}
+
+// SignatureV1Allowed return true unless legacy signature messages are denied in config
+func (p *SorobanConfig) SignatureV1Allowed() bool {
+	return p.AllowSignatureV1 == nil || *p.AllowSignatureV1
+}
+
+// Parse yaml config, unknown fields are rejected
 func (p *SorobanConfig) Parse(data []byte) error {
-	return yaml.Unmarshal(data, p)
+	return yaml.UnmarshalStrict(data, p)
+}
+
+// Validate check algorithms, public keys encoding and prefix patterns
+func (p *SorobanConfig) Validate() error {
+	for i, entry := range p.Confidential {
+		if err := entry.Validate(); err != nil {
+			return fmt.Errorf("confidential entry %d (%s): %w", i, entry.Prefix, err)
+		}
+	}
+	return nil
+}
+
+// Validate check entry algorithm, public key encoding and prefix pattern
+func (p *ConfidentialEntry) Validate() error {
+	if len(p.Prefix) == 0 {
+		return errors.New("empty prefix")
+	}
+	if _, err := regexp.Compile(wildCardToRegexp(p.Prefix)); err != nil {
+		return err
+	}
+	if err := p.RateLimit.Key.Validate(); err != nil {
+		return err
+	}
+	if err := p.RateLimit.Name.Validate(); err != nil {
+		return err
+	}
+	if err := p.RateLimit.PublicKey.Validate(); err != nil {
+		return err
+	}
+	if len(p.Algorithm) == 0 && len(p.PublicKey) == 0 && !p.Confidential && !p.ReadOnly {
+		return nil
+	}
+	if len(p.Algorithm) == 0 || len(p.PublicKey) == 0 {
+		// rule would be silently ignored by VerifySignature
+		return errors.New("algorithm and publickey are required")
+	}
+	return ValidatePublicKey(p.Algorithm, p.PublicKey)
+}
+
+// Validate check rate & burst values
+func (p *RateLimit) Validate() error {
+	if p.Rate < 0 || p.Burst < 0 {
+		return errors.New("negative rate limit")
+	}
+	if p.Rate > 0 && p.Burst < 1 {
+		return errors.New("rate limit burst must be greater than 0")
+	}
+	return nil
 }
 
-func ConfigLoad(filename string) SorobanConfig {
+// ConfigLoad read, parse and validate config file
+func ConfigLoad(filename string) (SorobanConfig, error) {
 	data, err := ioutil.ReadFile(filename)
 	if err != nil {
-		log.WithError(err).WithField("Filename", filename).Error("Failed to load config")
-		return SorobanConfig{}
+		return SorobanConfig{}, err
+	}
+	return ConfigParse(data)
+}
+
+// ConfigParse parse and validate config data
+func ConfigParse(data []byte) (SorobanConfig, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		// file can be truncated while editor is writing
+		return SorobanConfig{}, errors.New("empty config file")
 	}
 	var config SorobanConfig
 	if err := config.Parse(data); err != nil {
-		log.WithError(err).WithField("Filename", filename).Error("Failed to parse config")
+		return SorobanConfig{}, err
+	}
+	if err := config.Validate(); err != nil {
+		return SorobanConfig{}, err
+	}
+	return config, nil
+}
+
+// configReload load config file and swap current config, previous config is kept on error
+func configReload(filename string) {
+	config, err := ConfigLoad(filename)
+	if err != nil {
+		log.WithError(err).WithField("Filename", filename).Error("Failed to reload config, keeping previous config")
+		return
+	}
+	if !setLocalConfig(config) {
+		log.WithField("Filename", filename).Warning("Config file ignored, using admin ruleset")
+		return
 	}
-	return config
+	log.WithField("Filename", filename).WithField("Count", len(config.Confidential)).Info("Config reloaded")
 }
 
 func ConfigWatcher(ctx context.Context, filename string) {
@@ -68,38 +185,56 @@ func ConfigWatcher(ctx context.Context, filename string) {
 	}
 	if _, err := os.Stat(filename); errors.Is(err, os.ErrNotExist) {
 		log.WithError(err).WithField("Filename", filename).Warning("Config file not found")
+	} else {
+		config, err := ConfigLoad(filename)
+		if err != nil {
+			log.WithError(err).WithField("Filename", filename).Fatal("Invalid config file")
+		}
+		setLocalConfig(config)
 	}
 
-	DefaultSorobanConfig = ConfigLoad(filename)
+	watchFile(ctx, filename, configReload)
+}
 
+// watchFile call reload when file is written, created or replaced
+func watchFile(ctx context.Context, filename string, reload func(filename string)) {
 	// configure fs watcher
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
-		log.WithError(err).WithField("Filename", filename).Error("Failed to create ConfigWatcher")
+		log.WithError(err).WithField("Filename", filename).Error("Failed to create file watcher")
 		return
 	}
 	defer watcher.Close()
 
 	// Start listening for events.
 	go func() {
+		// editors can write, rename or replace config file
+		// reload is delayed until events settle
+		var delay <-chan time.Time
 		for {
 			select {
 			case event, ok := <-watcher.Events:
 				if !ok {
 					return
 				}
-				if event.Op&fsnotify.Write == fsnotify.Write {
-					log.Info("Reloading config file")
-
-					log.Println("modified file:", event.Name)
-					DefaultSorobanConfig = ConfigLoad(event.Name)
+				if filepath.Clean(event.Name) != filepath.Clean(filename) {
+					continue
 				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+					log.WithField("Event", event.String()).Debug("File changed")
+					delay = time.After(250 * time.Millisecond)
+				}
+
+			case <-delay:
+				delay = nil
+				log.WithField("Filename", filename).Info("Reloading file")
+				reload(filename)
 
 			case err, ok := <-watcher.Errors:
 				if !ok {
 					return
 				}
-				log.WithError(err).WithField("Filename", filename).Error("ConfigWatcher error")
+				log.WithError(err).WithField("Filename", filename).Error("File watcher error")
 
 			case <-ctx.Done():
 				return
@@ -107,10 +242,10 @@ func ConfigWatcher(ctx context.Context, filename string) {
 		}
 	}()
 
-	// Add a path.
-	err = watcher.Add(filename)
+	// Watch parent directory to keep track of file replacements.
+	err = watcher.Add(filepath.Dir(filename))
 	if err != nil {
-		log.WithError(err).WithField("Filename", filename).Error("Failed to watch config file")
+		log.WithError(err).WithField("Filename", filename).Error("Failed to watch file")
 	}
 
 	<-ctx.Done()
@@ -157,11 +292,27 @@ func match(pattern string, value string) bool {
 	return false
 }
 
+// Match return true if directory matches prefix pattern, with the same syntax as confidential prefixes
+func Match(prefix, directory string) bool {
+	return match(prefix, directory)
+}
+
+// Protected return true if any rule matching directory is confidential or readonly.
+// Entries of protected directories must not be exchanged without their signed request.
+func Protected(directory string) bool {
+	for _, entry := range Config().Confidential {
+		if match(entry.Prefix, directory) && (entry.Confidential || entry.ReadOnly) {
+			return true
+		}
+	}
+	return false
+}
+
 func GetConfidentialInfo(directory, publicKey string) ConfidentialEntry {
 	var entries []ConfidentialEntry
 
 	// find all matching prefix
-	for _, entry := range DefaultSorobanConfig.Confidential {
+	for _, entry := range Config().Confidential {
 		if match(entry.Prefix, directory) {
 			entries = append(entries, entry)
 		}
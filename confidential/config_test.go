@@ -0,0 +1,70 @@
+package confidential
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+
+	tests := []struct {
+		name     string
+		filename string
+		want     int
+		wantErr  bool
+	}{
+		{"sample", "../confidential.yml", 4, false},
+		{"missing", filepath.Join(dir, "missing.yml"), 0, true},
+		{"empty", write("empty.yml", "\n"), 0, true},
+		{"typo", write("typo.yml", "confidential:\n  - prefix: foo\n    algoritm: nacl\n"), 0, true},
+		{"algorithm", write("algorithm.yml", "confidential:\n  - prefix: foo\n    algorithm: rsa\n    publickey: 00\n    readonly: true\n"), 0, true},
+		{"nacl-key", write("nacl.yml", "confidential:\n  - prefix: foo\n    algorithm: nacl\n    publickey: 6f39d76e\n    readonly: true\n"), 0, true},
+		{"ecdsa-key", write("ecdsa.yml", "confidential:\n  - prefix: foo\n    algorithm: ecdsa\n    publickey: zz\n    readonly: true\n"), 0, true},
+		{"address-net", write("net.yml", "confidential:\n  - prefix: foo\n    algorithm: mainnet\n    publickey: mi42XN9J3eLdZae4tjQnJnVkCcNDRuAtz4\n    readonly: true\n"), 0, true},
+		{"missing-key", write("key.yml", "confidential:\n  - prefix: foo\n    readonly: true\n"), 0, true},
+		{"valid", write("valid.yml", "confidential:\n  - prefix: foo.*\n    algorithm: testnet3\n    publickey: mi42XN9J3eLdZae4tjQnJnVkCcNDRuAtz4\n    readonly: true\n"), 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConfigLoad(tt.filename)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConfigLoad() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got.Confidential) != tt.want {
+				t.Errorf("ConfigLoad() = %d entries, want %d", len(got.Confidential), tt.want)
+			}
+		})
+	}
+}
+
+func Test_configReload(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "confidential.yml")
+	valid := "confidential:\n  - prefix: foo.*\n    algorithm: testnet3\n    publickey: mi42XN9J3eLdZae4tjQnJnVkCcNDRuAtz4\n    readonly: true\n"
+	if err := os.WriteFile(filename, []byte(valid), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer SetConfig(SorobanConfig{})
+
+	configReload(filename)
+	if len(Config().Confidential) != 1 {
+		t.Fatalf("configReload() = %d entries, want 1", len(Config().Confidential))
+	}
+
+	// invalid config must keep previous config
+	if err := os.WriteFile(filename, []byte("confidential: [\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	configReload(filename)
+	if len(Config().Confidential) != 1 {
+		t.Errorf("configReload() = %d entries, want previous config", len(Config().Confidential))
+	}
+}
@@ -0,0 +1,103 @@
+package confidential
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	SignatureV1 = 1
+	SignatureV2 = 2
+
+	signatureV2Tag = "soroban.v2"
+
+	// signatureV1WarningDelay between deprecation warnings of v1 messages
+	signatureV1WarningDelay = time.Minute
+)
+
+var (
+	signatureV1Warning       time.Time
+	signatureV1WarningLocker sync.Mutex
+)
+
+// SignatureVersion return effective version, unset version is v1
+func SignatureVersion(version int) int {
+	if version == 0 {
+		return SignatureV1
+	}
+	return version
+}
+
+// CheckSignatureVersion return an error if version is not accepted
+// v1 messages are deprecated, accepted unless allowsignaturev1 is set to false in config
+func CheckSignatureVersion(version int) error {
+	switch SignatureVersion(version) {
+	case SignatureV1:
+		config := Config()
+		if !config.SignatureV1Allowed() {
+			return Mismatch(errors.New("signature v1 not allowed"))
+		}
+		warnSignatureV1()
+		return nil
+	case SignatureV2:
+		return nil
+	default:
+		return Mismatch(errors.New("unknown signature version"))
+	}
+}
+
+// SignatureMessage return the message to sign for operation
+//
+// v1: `name.timestamp` for list, `name.timestamp.entry` for add & remove
+//
+// v2: `soroban.v2;` followed by netstrings of operation, domain, name, entry, mode, timestamp & nonce
+func SignatureMessage(version int, operation, domain, name, entry, mode string, timestamp int64, nonce string) (string, error) {
+	switch SignatureVersion(version) {
+	case SignatureV1:
+		if operation == OperationList {
+			return fmt.Sprintf("%s.%d", name, timestamp), nil
+		}
+		return fmt.Sprintf("%s.%d.%s", name, timestamp, entry), nil
+
+	case SignatureV2:
+		if len(operation) == 0 || len(domain) == 0 || len(name) == 0 {
+			return "", errors.New("invalid message fields")
+		}
+		if len(nonce) == 0 {
+			return "", errors.New("nonce is required")
+		}
+
+		var result strings.Builder
+		result.WriteString(signatureV2Tag)
+		result.WriteString(";")
+		for _, field := range []string{operation, domain, name, entry, mode, strconv.FormatInt(timestamp, 10), nonce} {
+			result.WriteString(netstring(field))
+		}
+		return result.String(), nil
+
+	default:
+		return "", errors.New("unknown signature version")
+	}
+}
+
+func netstring(value string) string {
+	return fmt.Sprintf("%d:%s,", len(value), value)
+}
+
+// warnSignatureV1 log deprecation of v1 messages, at most once per minute
+func warnSignatureV1() {
+	signatureV1WarningLocker.Lock()
+	defer signatureV1WarningLocker.Unlock()
+
+	if time.Since(signatureV1Warning) < signatureV1WarningDelay {
+		return
+	}
+	signatureV1Warning = time.Now()
+	log.Warning("Deprecated v1 signature message accepted, clients must migrate to v2 before allowsignaturev1 is set to false")
+}
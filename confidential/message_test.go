@@ -0,0 +1,69 @@
+package confidential
+
+import (
+	"testing"
+)
+
+func TestSignatureMessage(t *testing.T) {
+	type args struct {
+		version   int
+		operation string
+		domain    string
+		name      string
+		entry     string
+		mode      string
+		timestamp int64
+		nonce     string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{"v1-list", args{0, OperationList, "samourai", "foo", "", "", 42, ""}, "foo.42", false},
+		{"v1-add", args{SignatureV1, OperationAdd, "samourai", "foo", "bar", "short", 42, ""}, "foo.42.bar", false},
+		{"v2-add", args{SignatureV2, OperationAdd, "samourai", "foo", "bar", "short", 42, "n0"}, "soroban.v2;3:add,8:samourai,3:foo,3:bar,5:short,2:42,2:n0,", false},
+		{"v2-remove", args{SignatureV2, OperationRemove, "samourai", "foo", "bar", "short", 42, "n0"}, "soroban.v2;6:remove,8:samourai,3:foo,3:bar,5:short,2:42,2:n0,", false},
+		{"v2-nonce", args{SignatureV2, OperationAdd, "samourai", "foo", "bar", "short", 42, ""}, "", true},
+		{"unknown", args{3, OperationAdd, "samourai", "foo", "bar", "short", 42, "n0"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SignatureMessage(tt.args.version, tt.args.operation, tt.args.domain, tt.args.name, tt.args.entry, tt.args.mode, tt.args.timestamp, tt.args.nonce)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SignatureMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SignatureMessage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSignatureVersion(t *testing.T) {
+	defer SetConfig(Config())
+
+	allow := true
+	deny := false
+	tests := []struct {
+		name    string
+		allow   *bool
+		version int
+		wantErr bool
+	}{
+		{"v1-default", nil, SignatureV1, false},
+		{"v1-allowed", &allow, SignatureV1, false},
+		{"v1-denied", &deny, SignatureV1, true},
+		{"v2-denied", &deny, SignatureV2, false},
+		{"unknown", nil, 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(SorobanConfig{AllowSignatureV1: tt.allow})
+			if err := CheckSignatureVersion(tt.version); (err != nil) != tt.wantErr {
+				t.Errorf("CheckSignatureVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
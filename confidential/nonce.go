@@ -0,0 +1,66 @@
+package confidential
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// NonceTTL cover the timestamp window of signed messages, in the past and in the future
+	NonceTTL = 48 * time.Hour
+	// nonceMaxCount of nonces remembered, oldest nonces are evicted first
+	nonceMaxCount = 100000
+)
+
+var (
+	nonces = NewNonceCache(NonceTTL, nonceMaxCount)
+)
+
+// NonceCache remember nonces of signed v2 messages, per public key, to reject replayed requests
+type NonceCache struct {
+	ttl      time.Duration
+	maxCount int
+
+	mutex   sync.Mutex
+	entries map[string]time.Time
+	order   []string
+}
+
+func NewNonceCache(ttl time.Duration, maxCount int) *NonceCache {
+	return &NonceCache{
+		ttl:      ttl,
+		maxCount: maxCount,
+		entries:  make(map[string]time.Time),
+	}
+}
+
+// Use mark nonce of public key as used, return an error if it was already used
+func (p *NonceCache) Use(publicKey, nonce string) error {
+	if len(nonce) == 0 {
+		return nil
+	}
+	key := publicKey + ":" + nonce
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	// nonces are ordered by insertion, evict expired and oldest nonces
+	for len(p.order) > 0 && (len(p.order) >= p.maxCount || now.After(p.entries[p.order[0]])) {
+		delete(p.entries, p.order[0])
+		p.order = p.order[1:]
+	}
+
+	if expireOn, ok := p.entries[key]; ok && now.Before(expireOn) {
+		return errors.New("nonce already used")
+	}
+	p.entries[key] = now.Add(p.ttl)
+	p.order = append(p.order, key)
+	return nil
+}
+
+// CheckNonce reject replayed v2 requests, must be called once per request received from clients
+func CheckNonce(publicKey, nonce string) error {
+	return nonces.Use(publicKey, nonce)
+}
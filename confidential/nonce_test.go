@@ -0,0 +1,49 @@
+package confidential
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNonceCache(t *testing.T) {
+	cache := NewNonceCache(time.Hour, 2)
+
+	if err := cache.Use("key", "n0"); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+	if err := cache.Use("key", "n0"); err == nil {
+		t.Errorf("Use() replayed nonce accepted")
+	}
+	if err := cache.Use("other", "n0"); err != nil {
+		t.Errorf("Use() nonce of other key error = %v", err)
+	}
+	if err := cache.Use("key", ""); err != nil {
+		t.Errorf("Use() empty nonce error = %v", err)
+	}
+
+	// oldest nonce is evicted when cache is full
+	if err := cache.Use("key", "n1"); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+	if len(cache.entries) != 2 {
+		t.Errorf("Use() count = %d, want 2", len(cache.entries))
+	}
+	if err := cache.Use("key", "n0"); err != nil {
+		t.Errorf("Use() evicted nonce error = %v", err)
+	}
+}
+
+func TestNonceCacheExpired(t *testing.T) {
+	cache := NewNonceCache(time.Millisecond, 10)
+
+	if err := cache.Use("key", "n0"); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := cache.Use("key", "n0"); err != nil {
+		t.Errorf("Use() expired nonce error = %v", err)
+	}
+	if len(cache.order) != 1 {
+		t.Errorf("Use() count = %d, want 1", len(cache.order))
+	}
+}
@@ -0,0 +1,265 @@
+package confidential
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Ruleset is a versioned confidential config signed by the admin key.
+// Rulesets are distributed over p2p and adopted when signature and version check out.
+type Ruleset struct {
+	Version   uint64
+	Config    string
+	Algorithm string
+	PublicKey string
+	Signature string
+}
+
+const rulesetTag = "soroban.ruleset.v1"
+
+type rulesetAdmin struct {
+	Algorithm string
+	PublicKey string
+}
+
+var (
+	admin          rulesetAdmin
+	currentRuleset Ruleset
+	rulesetFile    string
+	rulesetLocker  sync.Mutex
+)
+
+// Message return the canonical message signed by the admin key,
+// `soroban.ruleset.v1;` followed by netstrings of version & config, distinct from entry messages
+func (p *Ruleset) Message() string {
+	var result strings.Builder
+	result.WriteString(rulesetTag)
+	result.WriteString(";")
+	for _, field := range []string{strconv.FormatUint(p.Version, 10), p.Config} {
+		result.WriteString(netstring(field))
+	}
+	return result.String()
+}
+
+// Verify check ruleset signature with admin key, return the validated config
+func (p *Ruleset) Verify(algorithm, publicKey string) (SorobanConfig, error) {
+	if p.Version == 0 {
+		return SorobanConfig{}, errors.New("invalid ruleset version")
+	}
+	if p.Algorithm != algorithm || p.PublicKey != publicKey {
+		return SorobanConfig{}, Mismatch(errors.New("ruleset not signed by admin key"))
+	}
+	alg, err := GetAlgorithm(algorithm)
+	if err != nil {
+		return SorobanConfig{}, err
+	}
+	err = alg.Verify(publicKey, p.Message(), p.Signature)
+	if err != nil {
+		return SorobanConfig{}, err
+	}
+	// config signed by admin may use fields of newer versions
+	config, err := ConfigParse([]byte(p.Config))
+	if err != nil {
+		return SorobanConfig{}, Mismatch(err)
+	}
+	return config, nil
+}
+
+// SignRuleset create a ruleset from config data signed with admin private key
+func SignRuleset(algorithm, privateKey string, version uint64, config []byte) (Ruleset, error) {
+	if _, err := ConfigParse(config); err != nil {
+		return Ruleset{}, err
+	}
+	alg, err := GetAlgorithm(algorithm)
+	if err != nil {
+		return Ruleset{}, err
+	}
+	publicKey, err := alg.PublicKey(privateKey)
+	if err != nil {
+		return Ruleset{}, err
+	}
+
+	result := Ruleset{
+		Version:   version,
+		Config:    string(config),
+		Algorithm: algorithm,
+		PublicKey: publicKey,
+	}
+	result.Signature, err = alg.Sign(privateKey, result.Message())
+	if err != nil {
+		return Ruleset{}, err
+	}
+	return result, nil
+}
+
+// SetAdmin enable admin ruleset mode with admin public key.
+// Adopted rulesets are saved to filename if not empty.
+func SetAdmin(algorithm, publicKey, filename string) error {
+	if err := ValidatePublicKey(algorithm, publicKey); err != nil {
+		return err
+	}
+
+	rulesetLocker.Lock()
+	defer rulesetLocker.Unlock()
+
+	admin = rulesetAdmin{
+		Algorithm: algorithm,
+		PublicKey: publicKey,
+	}
+	rulesetFile = filename
+	return nil
+}
+
+// AdminEnabled return true if rulesets can be adopted
+func AdminEnabled() bool {
+	rulesetLocker.Lock()
+	defer rulesetLocker.Unlock()
+
+	return len(admin.PublicKey) > 0
+}
+
+// Admin return admin key algorithm and public key, empty if disabled
+func Admin() (string, string) {
+	rulesetLocker.Lock()
+	defer rulesetLocker.Unlock()
+
+	return admin.Algorithm, admin.PublicKey
+}
+
+// RulesetVersion return adopted ruleset version, 0 if none
+func RulesetVersion() uint64 {
+	rulesetLocker.Lock()
+	defer rulesetLocker.Unlock()
+
+	return currentRuleset.Version
+}
+
+// CurrentRuleset return adopted ruleset, for republishing
+func CurrentRuleset() (Ruleset, bool) {
+	rulesetLocker.Lock()
+	defer rulesetLocker.Unlock()
+
+	return currentRuleset, currentRuleset.Version > 0
+}
+
+// setLocalConfig replace current config with local config file, unless an admin ruleset is adopted
+func setLocalConfig(config SorobanConfig) bool {
+	rulesetLocker.Lock()
+	defer rulesetLocker.Unlock()
+
+	if currentRuleset.Version > 0 {
+		return false
+	}
+	SetConfig(config)
+	return true
+}
+
+// AdoptRuleset replace current config if ruleset is signed by admin key and newer than current ruleset.
+// Return false if ruleset is already known or older.
+func AdoptRuleset(ruleset Ruleset) (bool, error) {
+	rulesetLocker.Lock()
+	defer rulesetLocker.Unlock()
+
+	if len(admin.PublicKey) == 0 {
+		return false, errors.New("admin ruleset disabled")
+	}
+	if ruleset.Version <= currentRuleset.Version {
+		return false, nil
+	}
+	config, err := ruleset.Verify(admin.Algorithm, admin.PublicKey)
+	if err != nil {
+		return false, err
+	}
+
+	currentRuleset = ruleset
+	SetConfig(config)
+	log.WithField("Version", ruleset.Version).WithField("Count", len(config.Confidential)).Info("Admin ruleset adopted")
+
+	if len(rulesetFile) > 0 {
+		if err := rulesetSave(rulesetFile, ruleset); err != nil {
+			log.WithError(err).WithField("Filename", rulesetFile).Error("Failed to save ruleset")
+		}
+	}
+	return true, nil
+}
+
+// RulesetLoad read ruleset file
+func RulesetLoad(filename string) (Ruleset, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return Ruleset{}, err
+	}
+	var result Ruleset
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return Ruleset{}, err
+	}
+	return result, nil
+}
+
+func rulesetSave(filename string, ruleset Ruleset) error {
+	data, err := json.MarshalIndent(&ruleset, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// replace file atomically
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), ".ruleset-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+func rulesetReload(filename string) {
+	ruleset, err := RulesetLoad(filename)
+	if err != nil {
+		log.WithError(err).WithField("Filename", filename).Error("Failed to load ruleset")
+		return
+	}
+	_, err = AdoptRuleset(ruleset)
+	if err != nil {
+		log.WithError(err).WithField("Filename", filename).Error("Failed to adopt ruleset")
+	}
+}
+
+// RulesetWatcher load ruleset file on startup and on changes
+func RulesetWatcher(ctx context.Context, filename string) {
+	if len(filename) == 0 {
+		return // Noop
+	}
+	if _, err := os.Stat(filename); errors.Is(err, os.ErrNotExist) {
+		log.WithField("Filename", filename).Info("Ruleset file not found, waiting for admin ruleset")
+	} else {
+		rulesetReload(filename)
+	}
+
+	watchFile(ctx, filename, rulesetReload)
+}
+
+// VerifyRuleset check ruleset signature with admin key, without adopting it
+func VerifyRuleset(ruleset Ruleset) error {
+	algorithm, publicKey := Admin()
+	if len(publicKey) == 0 {
+		return errors.New("admin ruleset disabled")
+	}
+	_, err := ruleset.Verify(algorithm, publicKey)
+	return err
+}
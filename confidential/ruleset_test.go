@@ -0,0 +1,83 @@
+package confidential
+
+import (
+	"os"
+	"testing"
+)
+
+func TestAdoptRuleset(t *testing.T) {
+	const adminKey = "L3xJb1qTaa5DUpmMgb2yKMy9n1nxCYAPuMhA34EeZ3Ua2Xr9wyDF"
+	const adminPublicKey = "024d1d2028d6a503c5d688425eddcb9a348696d606fb6d521b8a336de760d51e8e"
+
+	data, err := os.ReadFile("../confidential.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		SetConfig(SorobanConfig{})
+		admin = rulesetAdmin{}
+		currentRuleset = Ruleset{}
+		rulesetFile = ""
+	}()
+
+	v1, err := SignRuleset(AlgorithmEcdsa, adminKey, 1, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := SignRuleset(AlgorithmEcdsa, adminKey, 2, []byte("confidential: []\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey := newTestKey(t)
+	tampered := v2
+	tampered.Version = 3
+
+	if _, err := AdoptRuleset(v1); err == nil {
+		t.Fatalf("AdoptRuleset() adopted with admin disabled")
+	}
+	if err := SetAdmin(AlgorithmEcdsa, adminPublicKey, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		ruleset     Ruleset
+		want        bool
+		wantErr     bool
+		wantVersion uint64
+	}{
+		{"v1", v1, true, false, 1},
+		{"v1-again", v1, false, false, 1},
+		{"tampered", tampered, false, true, 1},
+		{"v2", v2, true, false, 2},
+		{"rollback", v1, false, false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AdoptRuleset(tt.ruleset)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AdoptRuleset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("AdoptRuleset() = %v, want %v", got, tt.want)
+			}
+			if version := RulesetVersion(); version != tt.wantVersion {
+				t.Errorf("RulesetVersion() = %v, want %v", version, tt.wantVersion)
+			}
+		})
+	}
+
+	if _, err := v1.Verify(AlgorithmEcdsa, otherKey); err == nil {
+		t.Errorf("Verify() accepted ruleset for another admin key")
+	}
+	if setLocalConfig(SorobanConfig{}) {
+		t.Errorf("setLocalConfig() replaced adopted ruleset")
+	}
+}
+
+func TestRulesetMessage(t *testing.T) {
+	ruleset := Ruleset{Version: 12, Config: "confidential: []"}
+	if got, want := ruleset.Message(), "soroban.ruleset.v1;2:12,16:confidential: [],"; got != want {
+		t.Errorf("Message() = %q, want %q", got, want)
+	}
+}
@@ -1,6 +1,9 @@
 package confidential
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
 
@@ -11,160 +14,313 @@ import (
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
 
 	verifier "github.com/bitonicnl/verify-signed-message/pkg"
 
 	log "github.com/sirupsen/logrus"
 )
 
-func toNaclPubKey(publicKey string) *[32]byte {
-	var result [32]byte
-	key, err := hex.DecodeString(publicKey)
-	if err != nil {
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrMismatch is matched by errors depending on local config or clock, not on signature validity.
+	// Messages failing with it may be valid for peers with another config, during config or key rollouts.
+	ErrMismatch = errors.New("local config mismatch")
+
+	testnet3Params = &chaincfg.TestNet3Params
+	mainnetParams  = &chaincfg.MainNetParams
+)
+
+type mismatchError struct {
+	error
+}
+
+func (e mismatchError) Is(target error) bool {
+	return target == ErrMismatch
+}
+
+func (e mismatchError) Unwrap() error {
+	return e.error
+}
+
+// Mismatch return err matching ErrMismatch, error message is unchanged
+func Mismatch(err error) error {
+	if err == nil {
 		return nil
 	}
-	copy(result[:], key[:32])
-	return &result
+	return mismatchError{err}
+}
+
+// ValidatePublicKey check public key encoding for algorithm
+func ValidatePublicKey(algorithm, publicKey string) error {
+	alg, err := GetAlgorithm(algorithm)
+	if err != nil {
+		return err
+	}
+	return alg.ValidatePublicKey(publicKey)
 }
 
 // VerifySignature check signature with publicKey and message
-// Support Nacl and Ecdsa Algorithms
+// Support all registered algorithms
 func VerifySignature(info ConfidentialEntry, publicKey, message, algorithm, signature string) error {
 	if len(info.Prefix) == 0 || len(info.Algorithm) == 0 || len(info.PublicKey) == 0 {
 		return nil
 	}
 	log.WithField("Info", info).Debug("Verify Signature")
-	switch info.Algorithm {
-	case AlgorithmNacl:
-		if info.Algorithm != algorithm {
-			return errors.New("algorithm not maching")
-		}
-		if info.PublicKey != publicKey {
-			return errors.New("publicKey not maching")
-		}
-
-		verified := verifyNaclSignature(publicKey, message, signature)
-		if !verified {
-			return errors.New("invalid singature")
-		}
-
-		log.Debug("Signature verified")
-		return nil
 
-	case AlgorithmEcdsa:
-		if info.PublicKey != publicKey {
-			return errors.New("publicKey not maching")
-		}
+	alg, err := GetAlgorithm(info.Algorithm)
+	if err != nil {
+		return err
+	}
+	if info.Algorithm == AlgorithmNacl && info.Algorithm != algorithm {
+		return Mismatch(errors.New("algorithm not maching"))
+	}
+	if info.PublicKey != publicKey {
+		return Mismatch(errors.New("publicKey not maching"))
+	}
 
-		verified := verifyEcdsaSignature(publicKey, message, signature)
-		if !verified {
-			return errors.New("invalid singature")
-		}
+	err = alg.Verify(publicKey, message, signature)
+	if err != nil {
+		return err
+	}
 
-		log.Debug("Signature verified")
-		return nil
+	log.Debug("Signature verified")
+	return nil
+}
 
-	case AlgorithmTestnet3:
-		if info.PublicKey != publicKey {
-			return errors.New("publicKey not maching")
-		}
+func signMessage(privateKey, message string) string {
+	signature, err := ecdsaAlgorithm{}.Sign(privateKey, message)
+	if err != nil {
+		panic(err)
+	}
+	return signature
+}
 
-		verified := verifyTestnet3Signature(publicKey, message, signature)
-		if !verified {
-			return errors.New("invalid singature")
-		}
+// nacl: hex encoded ed25519 keys, detached signature
 
-		log.Debug("Signature verified")
-		return nil
+type naclAlgorithm struct{}
 
-	case AlgorithmMainnet:
-		if info.PublicKey != publicKey {
-			return errors.New("publicKey not maching")
-		}
+func toNaclPubKey(publicKey string) (*[32]byte, error) {
+	var result [32]byte
+	key, err := hex.DecodeString(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != len(result) {
+		return nil, errors.New("invalid nacl public key length")
+	}
+	copy(result[:], key)
+	return &result, nil
+}
 
-		verified := verifyMainnetSignature(publicKey, message, signature)
-		if !verified {
-			return errors.New("invalid singature")
-		}
+// toNaclPrivKey accept ed25519 seed or private key
+func toNaclPrivKey(privateKey string) (*[64]byte, error) {
+	var result [64]byte
+	key, err := hex.DecodeString(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	switch len(key) {
+	case ed25519.SeedSize:
+		copy(result[:], ed25519.NewKeyFromSeed(key))
+	case ed25519.PrivateKeySize:
+		copy(result[:], key)
+	default:
+		return nil, errors.New("invalid nacl private key length")
+	}
+	return &result, nil
+}
 
-		log.Debug("Signature verified")
-		return nil
+func (naclAlgorithm) ValidatePublicKey(publicKey string) error {
+	_, err := toNaclPubKey(publicKey)
+	return err
+}
 
-	default:
-		return errors.New("unknown signature algorithm")
+func (naclAlgorithm) PublicKey(privateKey string) (string, error) {
+	key, err := toNaclPrivKey(privateKey)
+	if err != nil {
+		return "", err
 	}
+	return hex.EncodeToString(key[32:]), nil
 }
 
-func signMessage(privateKey, message string) string {
+func (naclAlgorithm) Sign(privateKey, message string) (string, error) {
+	key, err := toNaclPrivKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	signedMessage := sign.Sign(nil, []byte(message), key)
+	return hex.EncodeToString(signedMessage[:sign.Overhead]), nil
+}
+
+func (naclAlgorithm) Verify(publicKey, message, signature string) error {
+	return verifyNaclSignature(publicKey, message, signature)
+}
+
+func verifyNaclSignature(publicKey, message, signature string) error {
+	pubKey, err := toNaclPubKey(publicKey)
+	if err != nil {
+		return err
+	}
+	signedMessage, err := hex.DecodeString(signature)
+	if err != nil {
+		return err
+	}
+	if len(signedMessage) != sign.Overhead {
+		return errors.New("invalid nacl signature length")
+	}
+	signedMessage = append(signedMessage, []byte(message)...)
+
+	_, verified := sign.Open(nil, signedMessage, pubKey)
+	if !verified {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ecdsa: hex encoded compressed public key, WIF private key, hex DER signature of double sha256
+
+type ecdsaAlgorithm struct{}
+
+func (ecdsaAlgorithm) ValidatePublicKey(publicKey string) error {
+	key, err := hex.DecodeString(publicKey)
+	if err != nil {
+		return err
+	}
+	_, err = btcec.ParsePubKey(key)
+	return err
+}
+
+func (ecdsaAlgorithm) PublicKey(privateKey string) (string, error) {
 	wif, err := btcutil.DecodeWIF(privateKey)
 	if err != nil {
-		panic(err)
+		return "", err
 	}
+	return hex.EncodeToString(wif.PrivKey.PubKey().SerializeCompressed()), nil
+}
 
-	privKey := wif.PrivKey
-	pubKey := privKey.PubKey()
-	log.Printf("%v %d\n", hex.EncodeToString(pubKey.SerializeCompressed()), len(pubKey.SerializeCompressed()))
+func (ecdsaAlgorithm) Sign(privateKey, message string) (string, error) {
+	wif, err := btcutil.DecodeWIF(privateKey)
+	if err != nil {
+		return "", err
+	}
 
 	messageHash := chainhash.DoubleHashB([]byte(message))
-	signature := ecdsa.Sign(privKey, messageHash)
+	signature := ecdsa.Sign(wif.PrivKey, messageHash)
 
-	return hex.EncodeToString(signature.Serialize())
+	return hex.EncodeToString(signature.Serialize()), nil
 }
 
-func verifyNaclSignature(publicKey, message, signature string) bool {
-	signedMessage, _ := hex.DecodeString(signature)
-	signedMessage = append(signedMessage, []byte(message)...)
-
-	_, verified := sign.Open(nil, signedMessage, toNaclPubKey(publicKey))
-	return verified
+func (ecdsaAlgorithm) Verify(publicKey, message, signature string) error {
+	return verifyEcdsaSignature(publicKey, message, signature)
 }
 
-func verifyEcdsaSignature(publicKey, message, signature string) bool {
+func verifyEcdsaSignature(publicKey, message, signature string) error {
 	pubKeyBytes, err := hex.DecodeString(publicKey)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	pubKey, err := btcec.ParsePubKey(pubKeyBytes)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	sigBytes, err := hex.DecodeString(signature)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	sign, err := ecdsa.ParseSignature(sigBytes)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	messageHash := chainhash.DoubleHashB([]byte(message))
 
-	return sign.Verify(messageHash, pubKey)
+	if !sign.Verify(messageHash, pubKey) {
+		return ErrInvalidSignature
+	}
+	return nil
 }
 
-func verifyTestnet3Signature(publicKey, message, signature string) bool {
-	result, err := verifier.VerifyWithChain(verifier.SignedMessage{
-		Address:   publicKey,
-		Message:   message,
-		Signature: signature,
-	}, &chaincfg.TestNet3Params)
+// testnet3 & mainnet: bitcoin address, WIF private key, base64 bitcoin signed message
+
+type bitcoinAlgorithm struct {
+	params *chaincfg.Params
+}
+
+const bitcoinMessageMagic = "Bitcoin Signed Message:\n"
+
+func (p bitcoinAlgorithm) ValidatePublicKey(publicKey string) error {
+	addr, err := btcutil.DecodeAddress(publicKey, p.params)
 	if err != nil {
-		return false
+		return err
+	}
+	if !addr.IsForNet(p.params) {
+		return errors.New("address network not maching")
 	}
+	return nil
+}
 
-	return result
+// PublicKey return P2PKH address of private key
+func (p bitcoinAlgorithm) PublicKey(privateKey string) (string, error) {
+	wif, err := btcutil.DecodeWIF(privateKey)
+	if err != nil {
+		return "", err
+	}
+	addr, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(wif.SerializePubKey()), p.params)
+	if err != nil {
+		return "", err
+	}
+	return addr.EncodeAddress(), nil
 }
 
-func verifyMainnetSignature(publicKey, message, signature string) bool {
+func (p bitcoinAlgorithm) Sign(privateKey, message string) (string, error) {
+	wif, err := btcutil.DecodeWIF(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	err = wire.WriteVarString(&buffer, 0, bitcoinMessageMagic)
+	if err != nil {
+		return "", err
+	}
+	err = wire.WriteVarString(&buffer, 0, message)
+	if err != nil {
+		return "", err
+	}
+	messageHash := chainhash.DoubleHashB(buffer.Bytes())
+
+	signature, err := ecdsa.SignCompact(wif.PrivKey, messageHash, wif.CompressPubKey)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+func (p bitcoinAlgorithm) Verify(publicKey, message, signature string) error {
+	return verifyBitcoinSignature(publicKey, message, signature, p.params)
+}
+
+func verifyTestnet3Signature(publicKey, message, signature string) error {
+	return verifyBitcoinSignature(publicKey, message, signature, testnet3Params)
+}
+
+func verifyMainnetSignature(publicKey, message, signature string) error {
+	return verifyBitcoinSignature(publicKey, message, signature, mainnetParams)
+}
+
+func verifyBitcoinSignature(address, message, signature string, params *chaincfg.Params) error {
 	result, err := verifier.VerifyWithChain(verifier.SignedMessage{
-		Address:   publicKey,
+		Address:   address,
 		Message:   message,
 		Signature: signature,
-	}, &chaincfg.MainNetParams)
+	}, params)
 	if err != nil {
-		return false
+		return err
 	}
-
-	return result
+	if !result {
+		return ErrInvalidSignature
+	}
+	return nil
 }
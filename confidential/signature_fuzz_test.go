@@ -0,0 +1,62 @@
+package confidential
+
+import (
+	"testing"
+)
+
+// Verifiers must never panic, whatever the client sends.
+// Run with `go test -fuzz=FuzzVerifyEcdsaSignature ./confidential`
+
+func FuzzVerifyNaclSignature(f *testing.F) {
+	f.Add("6f39d76e3065f1fa9224b2ad261575da89f31275def4981f6de19428909683cf", "foo.42", "00")
+	f.Add("6f39d76e", "foo.42", "")
+	f.Add("zz", "", "zz")
+	f.Fuzz(func(t *testing.T, publicKey, message, signature string) {
+		if verifyNaclSignature(publicKey, message, signature) == nil {
+			t.Errorf("verifyNaclSignature() verified random signature")
+		}
+	})
+}
+
+func FuzzVerifyEcdsaSignature(f *testing.F) {
+	f.Add("024d1d2028d6a503c5d688425eddcb9a348696d606fb6d521b8a336de760d51e8e", "Hello, World!", "30440220046e86f0bff9639a893616e1db3abfa24cafa8818e7e47798c860d5982968ef502200241904a24128f6f73b8f5675368ff85992aa2b97bb40fe91ab361c96c62ca35")
+	f.Add("024d1d2028d6a503c5d688425eddcb9a348696d606fb6d521b8a336de760d51e8e", "Hello, World!", "3044")
+	f.Add("02", "", "")
+	f.Fuzz(func(t *testing.T, publicKey, message, signature string) {
+		_ = verifyEcdsaSignature(publicKey, message, signature)
+	})
+}
+
+func FuzzVerifyTestnet3Signature(f *testing.F) {
+	f.Add("mi42XN9J3eLdZae4tjQnJnVkCcNDRuAtz4", "hello", "IOMVJ0SDwbDs1zb3IV/MxEeNRwn8FA+2ZZlmtE6HzGEeMxm2lSDNSHoJmNCCNghIPHAJxWg6smIrItgvzofllEg=")
+	f.Add("tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", "hello", "IOMV")
+	f.Add("", "", "")
+	f.Fuzz(func(t *testing.T, publicKey, message, signature string) {
+		_ = verifyTestnet3Signature(publicKey, message, signature)
+	})
+}
+
+func FuzzVerifyMainnetSignature(f *testing.F) {
+	f.Add("1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "hello", "IOMVJ0SDwbDs1zb3IV/MxEeNRwn8FA+2ZZlmtE6HzGEeMxm2lSDNSHoJmNCCNghIPHAJxWg6smIrItgvzofllEg=")
+	f.Add("bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", "hello", "")
+	f.Add("", "", "")
+	f.Fuzz(func(t *testing.T, publicKey, message, signature string) {
+		_ = verifyMainnetSignature(publicKey, message, signature)
+	})
+}
+
+func FuzzVerifySignature(f *testing.F) {
+	f.Add(AlgorithmNacl, "6f39d76e3065f1fa9224b2ad261575da89f31275def4981f6de19428909683cf", "foo", "00")
+	f.Add(AlgorithmEcdsa, "024d1d2028d6a503c5d688425eddcb9a348696d606fb6d521b8a336de760d51e8e", "foo", "00")
+	f.Add(AlgorithmTestnet3, "mi42XN9J3eLdZae4tjQnJnVkCcNDRuAtz4", "foo", "00")
+	f.Add(AlgorithmMainnet, "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "foo", "00")
+	f.Fuzz(func(t *testing.T, algorithm, publicKey, message, signature string) {
+		info := ConfidentialEntry{
+			Prefix:    "foo",
+			Algorithm: algorithm,
+			PublicKey: publicKey,
+			ReadOnly:  true,
+		}
+		_ = VerifySignature(info, publicKey, message, algorithm, signature)
+	})
+}
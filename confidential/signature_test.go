@@ -40,7 +40,7 @@ func Test_verifyEcdsaSignature(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := verifyEcdsaSignature(tt.args.publicKey, tt.args.message, tt.args.signature); got != tt.want {
+			if got := verifyEcdsaSignature(tt.args.publicKey, tt.args.message, tt.args.signature) == nil; got != tt.want {
 				t.Errorf("verifyEcdsaSignature() = %v, want %v", got, tt.want)
 			}
 		})
@@ -65,9 +65,47 @@ func Test_verifyTestnet3Signature(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := verifyTestnet3Signature(tt.args.publicKey, tt.args.message, tt.args.signature); got != tt.want {
+			if got := verifyTestnet3Signature(tt.args.publicKey, tt.args.message, tt.args.signature) == nil; got != tt.want {
 				t.Errorf("verifyTestnet3Signature() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestSignMessage(t *testing.T) {
+	tests := []struct {
+		algorithm  string
+		privateKey string
+	}{
+		{AlgorithmNacl, "169fc9f1925eec11b6a728044c9f4e6dd1a676a4f4e6f640c4100015644914e8"},
+		{AlgorithmEcdsa, "L3xJb1qTaa5DUpmMgb2yKMy9n1nxCYAPuMhA34EeZ3Ua2Xr9wyDF"},
+		{AlgorithmTestnet3, "cUKJ3vqK1dmUeGEd4zr6ggUDQF6MrzG5yPqd9UhA4A8aHGuKyf3y"},
+		{AlgorithmMainnet, "L3xJb1qTaa5DUpmMgb2yKMy9n1nxCYAPuMhA34EeZ3Ua2Xr9wyDF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			alg, err := GetAlgorithm(tt.algorithm)
+			if err != nil {
+				t.Fatal(err)
+			}
+			publicKey, err := alg.PublicKey(tt.privateKey)
+			if err != nil {
+				t.Fatalf("PublicKey() error = %v", err)
+			}
+			if err := alg.ValidatePublicKey(publicKey); err != nil {
+				t.Fatalf("ValidatePublicKey() error = %v", err)
+			}
+			signature, err := SignMessage(tt.algorithm, tt.privateKey, "hello")
+			if err != nil {
+				t.Fatalf("SignMessage() error = %v", err)
+			}
+			info := ConfidentialEntry{Prefix: "foo", Algorithm: tt.algorithm, PublicKey: publicKey}
+			if err := VerifySignature(info, publicKey, "hello", tt.algorithm, signature); err != nil {
+				t.Errorf("VerifySignature() error = %v", err)
+			}
+			if err := VerifySignature(info, publicKey, "hello_failed", tt.algorithm, signature); err == nil {
+				t.Errorf("VerifySignature() verified wrong message")
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package confidential
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	OperationList   = "list"
+	OperationAdd    = "add"
+	OperationRemove = "remove"
+
+	// admin operations, signed by admin key
+	OperationBan   = "ban"
+	OperationUnban = "unban"
+
+	// MaxTokenChain limit delegation depth from rule key
+	MaxTokenChain = 4
+)
+
+// Token delegates a prefix subset and an operation set to an ephemeral public key
+// The token is signed by Issuer, which is the rule key for the first token of a chain,
+// or the delegated key of the previous token.
+type Token struct {
+	Issuer     string
+	Prefixes   []string
+	Operations []string
+	Algorithm  string
+	PublicKey  string
+	Expiry     int64
+	Signature  string
+}
+
+// Message return the canonical message signed by the token issuer
+func (p *Token) Message() string {
+	return fmt.Sprintf("soroban.token.%s.%s.%s.%s.%s.%d",
+		p.Issuer,
+		p.Algorithm,
+		p.PublicKey,
+		strings.Join(p.Prefixes, ","),
+		strings.Join(p.Operations, ","),
+		p.Expiry,
+	)
+}
+
+func (p *Token) allowOperation(operation string) bool {
+	for _, op := range p.Operations {
+		if op == operation {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Token) allowDirectory(directory string) bool {
+	for _, prefix := range p.Prefixes {
+		if match(prefix, directory) {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyDelegation check the token chain from the rule key down to the last delegated key.
+// Each token must be signed by its issuer, not expired and allow directory and operation.
+// Return a ConfidentialEntry with the delegated algorithm and public key, to be used for entry signature.
+func VerifyDelegation(info ConfidentialEntry, tokens []Token, directory, operation string, now time.Time) (ConfidentialEntry, error) {
+	if len(tokens) == 0 {
+		return ConfidentialEntry{}, errors.New("empty token chain")
+	}
+	if len(tokens) > MaxTokenChain {
+		return ConfidentialEntry{}, Mismatch(errors.New("token chain too long"))
+	}
+
+	issuer := info
+	for _, token := range tokens {
+		if token.Issuer != issuer.PublicKey {
+			return ConfidentialEntry{}, Mismatch(errors.New("token issuer not maching"))
+		}
+		if len(token.Algorithm) == 0 || len(token.PublicKey) == 0 {
+			return ConfidentialEntry{}, errors.New("invalid token public key")
+		}
+		if now.Unix() >= token.Expiry {
+			return ConfidentialEntry{}, Mismatch(errors.New("token expired"))
+		}
+		if !token.allowOperation(operation) {
+			return ConfidentialEntry{}, Mismatch(errors.New("operation not allowed by token"))
+		}
+		if !token.allowDirectory(directory) {
+			return ConfidentialEntry{}, Mismatch(errors.New("directory not allowed by token"))
+		}
+
+		err := VerifySignature(issuer, token.Issuer, token.Message(), issuer.Algorithm, token.Signature)
+		if err != nil {
+			return ConfidentialEntry{}, err
+		}
+
+		// next token must be signed by delegated key
+		issuer = ConfidentialEntry{
+			Prefix:       info.Prefix,
+			Algorithm:    token.Algorithm,
+			PublicKey:    token.PublicKey,
+			Confidential: info.Confidential,
+			ReadOnly:     info.ReadOnly,
+		}
+	}
+
+	return issuer, nil
+}
@@ -0,0 +1,90 @@
+package confidential
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+func newTestKey(t *testing.T) (string, string) {
+	privKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wif, err := btcutil.NewWIF(privKey, &chaincfg.MainNetParams, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wif.String(), hex.EncodeToString(privKey.PubKey().SerializeCompressed())
+}
+
+func signToken(token Token, privateKey string) Token {
+	token.Signature = signMessage(privateKey, token.Message())
+	return token
+}
+
+func TestVerifyDelegation(t *testing.T) {
+	rulePriv, rulePub := newTestKey(t)
+	delegatePriv, delegatePub := newTestKey(t)
+	_, ephemeralPub := newTestKey(t)
+
+	info := ConfidentialEntry{
+		Prefix:    "samourai.configuration.*",
+		Algorithm: AlgorithmEcdsa,
+		PublicKey: rulePub,
+		ReadOnly:  true,
+	}
+
+	now := time.Now()
+	root := Token{
+		Issuer:     rulePub,
+		Prefixes:   []string{"samourai.configuration.coordinator.*"},
+		Operations: []string{OperationAdd},
+		Algorithm:  AlgorithmEcdsa,
+		PublicKey:  delegatePub,
+		Expiry:     now.Add(time.Hour).Unix(),
+	}
+	child := Token{
+		Issuer:     delegatePub,
+		Prefixes:   []string{"samourai.configuration.coordinator.*"},
+		Operations: []string{OperationAdd},
+		Algorithm:  AlgorithmEcdsa,
+		PublicKey:  ephemeralPub,
+		Expiry:     now.Add(time.Hour).Unix(),
+	}
+	expired := root
+	expired.Expiry = now.Add(-time.Minute).Unix()
+
+	tests := []struct {
+		name      string
+		tokens    []Token
+		directory string
+		operation string
+		want      string
+		wantErr   bool
+	}{
+		{"delegated", []Token{signToken(root, rulePriv)}, "samourai.configuration.coordinator.1", OperationAdd, delegatePub, false},
+		{"chain", []Token{signToken(root, rulePriv), signToken(child, delegatePriv)}, "samourai.configuration.coordinator.1", OperationAdd, ephemeralPub, false},
+		{"empty", nil, "samourai.configuration.coordinator.1", OperationAdd, "", true},
+		{"bad-signature", []Token{signToken(root, delegatePriv)}, "samourai.configuration.coordinator.1", OperationAdd, "", true},
+		{"bad-chain", []Token{signToken(root, rulePriv), signToken(child, rulePriv)}, "samourai.configuration.coordinator.1", OperationAdd, "", true},
+		{"expired", []Token{signToken(expired, rulePriv)}, "samourai.configuration.coordinator.1", OperationAdd, "", true},
+		{"operation", []Token{signToken(root, rulePriv)}, "samourai.configuration.coordinator.1", OperationRemove, "", true},
+		{"prefix", []Token{signToken(root, rulePriv)}, "samourai.configuration.other", OperationAdd, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyDelegation(info, tt.tokens, tt.directory, tt.operation, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyDelegation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.PublicKey != tt.want {
+				t.Errorf("VerifyDelegation() = %v, want %v", got.PublicKey, tt.want)
+			}
+		})
+	}
+}
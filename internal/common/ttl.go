@@ -4,6 +4,11 @@ import (
 	"time"
 )
 
+// MaxTimeToLive return the longest duration of modes, items received from peers can't live longer.
+func MaxTimeToLive() time.Duration {
+	return TimeToLive("long")
+}
+
 // TimeToLive return duration from mode.
 func TimeToLive(mode string) time.Duration {
 	if len(mode) == 0 {
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	soroban "code.samourai.io/wallet/samourai-soroban"
+	"code.samourai.io/wallet/samourai-soroban/internal/ratelimit"
 	"code.samourai.io/wallet/samourai-soroban/ipc"
 	"code.samourai.io/wallet/samourai-soroban/p2p"
 )
@@ -14,6 +15,8 @@ const (
 	SorobanDirectoryKey = ContextKey("soroban-directory")
 	SorobanP2PKey       = ContextKey("soroban-p2p")
 	SorobanIPCKey       = ContextKey("soroban-ipc")
+	SorobanDomainKey    = ContextKey("soroban-domain")
+	SorobanRateLimitKey = ContextKey("soroban-ratelimit")
 )
 
 func DirectoryFromContext(ctx context.Context) soroban.Directory {
@@ -30,3 +33,13 @@ func IPCFromContext(ctx context.Context) *ipc.IPCService {
 	result, _ := ctx.Value(SorobanIPCKey).(*ipc.IPCService)
 	return result
 }
+
+func DomainFromContext(ctx context.Context) string {
+	result, _ := ctx.Value(SorobanDomainKey).(string)
+	return result
+}
+
+func RateLimiterFromContext(ctx context.Context) *ratelimit.Limiter {
+	result, _ := ctx.Value(SorobanRateLimitKey).(*ratelimit.Limiter)
+	return result
+}
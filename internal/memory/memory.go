@@ -1,6 +1,7 @@
 package memory
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -18,8 +19,12 @@ const (
 
 type Memory struct {
 	domain string
+	count  int
 	cache  libcache.Cache
 	mtx    sync.Mutex
+
+	// removed values, not imported again from peers until expired
+	removed map[string]time.Time
 }
 
 func New(count int, ttl time.Duration) *Memory {
@@ -31,8 +36,10 @@ func NewWithDomain(domain string, count int, ttl time.Duration) *Memory {
 	cache.SetTTL(ttl)
 
 	return &Memory{
-		domain: domain,
-		cache:  cache,
+		domain:  domain,
+		count:   count,
+		cache:   cache,
+		removed: make(map[string]time.Time),
 	}
 }
 
@@ -81,9 +88,12 @@ func (m *Memory) Add(key, value string, TTL time.Duration) error {
 		return common.InvalidArgsErr
 	}
 
+	name := key
 	key = common.KeyHash(m.domain, key)
+	delete(m.removed, removedKey(key, value))
 
 	list := getKeyList(m.cache, key)
+	list.name = name
 	list.TTL = TTL
 
 	now := now()
@@ -119,6 +129,7 @@ func (m *Memory) Remove(key, value string) error {
 	}
 
 	key = common.KeyHash(m.domain, key)
+	m.addRemoved(key, value)
 
 	list := getKeyList(m.cache, key)
 	if _, pos := contains(list.values, value); pos != -1 {
@@ -135,12 +146,176 @@ func (m *Memory) Remove(key, value string) error {
 	return nil
 }
 
+// Export return non-expired items of at most limit keys greater than after, ordered by key and value.
+func (m *Memory) Export(after string, limit int) ([]soroban.DirectoryItem, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	var keys []string
+	for _, k := range m.cache.Keys() {
+		if key, ok := k.(string); ok && key > after {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	now := now()
+	count := 0
+	var result []soroban.DirectoryItem
+	for _, key := range keys {
+		if limit > 0 && count >= limit {
+			break
+		}
+		list := getKeyList(m.cache, key)
+		exported := false
+		for _, entry := range list.values {
+			if entry.expireOn.Before(now) {
+				continue
+			}
+			exported = true
+			result = append(result, soroban.DirectoryItem{
+				Key:      key,
+				Name:     list.name,
+				Value:    entry.value,
+				ExpireOn: entry.expireOn,
+			})
+		}
+		if exported {
+			count++
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Key != result[j].Key {
+			return result[i].Key < result[j].Key
+		}
+		return result[i].Value < result[j].Value
+	})
+	return result, nil
+}
+
+// Lookup return non-expired items of a directory name, ordered by value.
+func (m *Memory) Lookup(name string) ([]soroban.DirectoryItem, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if len(name) == 0 {
+		return nil, common.InvalidArgsErr
+	}
+
+	now := now()
+	key := common.KeyHash(m.domain, name)
+	list := getKeyList(m.cache, key)
+
+	var result []soroban.DirectoryItem
+	for _, entry := range list.values {
+		if entry.expireOn.Before(now) {
+			continue
+		}
+		result = append(result, soroban.DirectoryItem{
+			Key:      key,
+			Name:     name,
+			Value:    entry.value,
+			ExpireOn: entry.expireOn,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Value < result[j].Value
+	})
+	return result, nil
+}
+
+// Import merge items in directory, keeping latest expiration date.
+// Items without name or removed recently are ignored, expiration dates are capped to the longest TTL.
+func (m *Memory) Import(items []soroban.DirectoryItem) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	now := now()
+	maxExpireOn := now.Add(common.MaxTimeToLive())
+	lists := make(map[string]*keyList)
+	for _, item := range items {
+		if len(item.Key) == 0 || len(item.Name) == 0 || len(item.Value) == 0 || !item.ExpireOn.After(now) {
+			continue
+		}
+		if common.KeyHash(m.domain, item.Name) != item.Key {
+			continue
+		}
+		// removed values must not be resurrected by peers which missed the removal
+		if expireOn, ok := m.removed[removedKey(item.Key, item.Value)]; ok && now.Before(expireOn) {
+			continue
+		}
+		if item.ExpireOn.After(maxExpireOn) {
+			item.ExpireOn = maxExpireOn
+		}
+
+		list, ok := lists[item.Key]
+		if !ok {
+			list = getKeyList(m.cache, item.Key)
+			lists[item.Key] = list
+		}
+		if len(list.name) == 0 {
+			list.name = item.Name
+		}
+
+		exists, pos := contains(list.values, item.Value)
+		if !exists {
+			list.values = append(list.values, &valueEntry{
+				value:    item.Value,
+				expireOn: item.ExpireOn.UTC(),
+			})
+		} else if item.ExpireOn.After(list.values[pos].expireOn) {
+			list.values[pos].expireOn = item.ExpireOn.UTC()
+		}
+	}
+
+	for key, list := range lists {
+		// keep non-expired values
+		purgeKeyList(list, now)
+		if len(list.values) == 0 {
+			continue
+		}
+
+		// keep list until last value expires
+		for _, entry := range list.values {
+			if ttl := entry.expireOn.Sub(now); ttl > list.TTL {
+				list.TTL = ttl
+			}
+		}
+		m.cache.StoreWithTTL(key, list, list.TTL)
+	}
+
+	return nil
+}
+
+// addRemoved remember removed value until copies of peers expire, must be called with mutex locked
+func (m *Memory) addRemoved(key, value string) {
+	now := now()
+	if len(m.removed) >= m.count {
+		for k, expireOn := range m.removed {
+			if !now.Before(expireOn) {
+				delete(m.removed, k)
+			}
+		}
+	}
+	if len(m.removed) >= m.count {
+		return
+	}
+	m.removed[removedKey(key, value)] = now.Add(common.MaxTimeToLive())
+}
+
+func removedKey(key, value string) string {
+	return key + "\x00" + value
+}
+
 type valueEntry struct {
 	expireOn time.Time
 	value    string
 }
 
 type keyList struct {
+	name   string
 	TTL    time.Duration
 	values []*valueEntry
 }
@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	soroban "code.samourai.io/wallet/samourai-soroban"
+	"code.samourai.io/wallet/samourai-soroban/internal/common"
+)
+
+func TestMemoryExportImport(t *testing.T) {
+	source := NewWithDomain("test", DefaultCacheCapacity, DefaultCacheTTL)
+	source.Add("key", "b", time.Minute)
+	source.Add("key", "a", time.Minute)
+	source.Add("other", "c", time.Minute)
+
+	items, err := source.Export("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("Export() count = %d, want 3", len(items))
+	}
+	for i := 1; i < len(items); i++ {
+		if items[i-1].Key > items[i].Key || (items[i-1].Key == items[i].Key && items[i-1].Value > items[i].Value) {
+			t.Fatalf("Export() not ordered: %v", items)
+		}
+	}
+
+	target := NewWithDomain("test", DefaultCacheCapacity, DefaultCacheTTL)
+	target.Add("key", "d", time.Minute)
+	err = target.Import(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, _ := target.List("key")
+	if len(values) != 3 {
+		t.Errorf("List() = %v, want 3 values", values)
+	}
+	values, _ = target.List("other")
+	if len(values) != 1 || values[0] != "c" {
+		t.Errorf("List() = %v, want [c]", values)
+	}
+}
+
+func TestMemoryLookup(t *testing.T) {
+	source := NewWithDomain("test", DefaultCacheCapacity, DefaultCacheTTL)
+	source.Add("key", "b", time.Minute)
+	source.Add("key", "a", 4*time.Minute)
+	source.Add("other", "c", time.Minute)
+
+	items, err := source.Lookup("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Value != "a" || items[1].Value != "b" || items[0].Name != "key" {
+		t.Fatalf("Lookup() = %v", items)
+	}
+
+	// items keep remaining TTL when imported
+	target := NewWithDomain("test", DefaultCacheCapacity, DefaultCacheTTL)
+	if err := target.Import(items); err != nil {
+		t.Fatal(err)
+	}
+	imported, _ := target.Lookup("key")
+	if len(imported) != 2 || !imported[0].ExpireOn.Equal(items[0].ExpireOn) {
+		t.Errorf("Lookup() imported = %v, want %v", imported, items)
+	}
+
+	if items, _ := source.Lookup("missing"); len(items) != 0 {
+		t.Errorf("Lookup() missing = %v", items)
+	}
+}
+
+func TestMemoryExportPages(t *testing.T) {
+	source := NewWithDomain("test", DefaultCacheCapacity, DefaultCacheTTL)
+	source.Add("key", "a", time.Minute)
+	source.Add("key", "b", time.Minute)
+	source.Add("other", "c", time.Minute)
+	source.Add("last", "d", time.Minute)
+
+	all, _ := source.Export("", 0)
+	var pages []soroban.DirectoryItem
+	after := ""
+	for i := 0; i < 3; i++ {
+		items, err := source.Export(after, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) == 0 {
+			t.Fatalf("Export() page %d empty", i)
+		}
+		// values of a key are never split
+		for _, item := range items {
+			if item.Key != items[0].Key {
+				t.Fatalf("Export() page %d with several keys: %v", i, items)
+			}
+		}
+		pages = append(pages, items...)
+		after = items[len(items)-1].Key
+	}
+	if items, _ := source.Export(after, 1); len(items) != 0 {
+		t.Errorf("Export() after last page = %v", items)
+	}
+	if len(pages) != len(all) {
+		t.Errorf("Export() pages = %v, want %v", pages, all)
+	}
+}
+
+func TestMemoryImportChecks(t *testing.T) {
+	source := NewWithDomain("test", DefaultCacheCapacity, DefaultCacheTTL)
+	source.Add("key", "a", time.Minute)
+	items, _ := source.Lookup("key")
+
+	target := NewWithDomain("test", DefaultCacheCapacity, DefaultCacheTTL)
+	now := time.Now()
+	err := target.Import([]soroban.DirectoryItem{
+		{Key: items[0].Key, Value: "unnamed", ExpireOn: now.Add(time.Minute)},
+		{Key: items[0].Key, Name: "key", Value: "forever", ExpireOn: now.Add(24 * time.Hour)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, _ := target.Lookup("key")
+	if len(imported) != 1 || imported[0].Value != "forever" {
+		t.Fatalf("Import() = %v, want [forever]", imported)
+	}
+	if imported[0].ExpireOn.After(time.Now().Add(common.MaxTimeToLive())) {
+		t.Errorf("Import() expire on = %v, not capped", imported[0].ExpireOn)
+	}
+
+	// removed values are not imported again from peers
+	target.Remove("key", "forever")
+	target.Import(items)
+	target.Import([]soroban.DirectoryItem{{Key: items[0].Key, Name: "key", Value: "forever", ExpireOn: now.Add(time.Minute)}})
+	if values, _ := target.List("key"); len(values) != 1 || values[0] != "a" {
+		t.Errorf("List() = %v, want [a]", values)
+	}
+
+	// values added again locally are not removed anymore
+	target.Add("key", "forever", time.Minute)
+	if len(target.removed) != 0 {
+		t.Errorf("Add() removed = %v, want none", target.removed)
+	}
+}
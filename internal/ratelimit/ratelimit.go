@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultCleanupDelay = 10 * time.Minute
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter is a token bucket rate limiter with one bucket per key
+type Limiter struct {
+	mtx     sync.Mutex
+	buckets map[string]*bucket
+}
+
+func New() *Limiter {
+	return &Limiter{
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow consume one token from key bucket.
+// Bucket is refilled with rate tokens per second, up to burst.
+// Rate lower or equals to zero disable the limit.
+func (l *Limiter) Allow(key string, rate float64, burst int) bool {
+	if rate <= 0 {
+		return true
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	now := time.Now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{
+			tokens: float64(burst),
+			last:   now,
+		}
+		l.buckets[key] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * rate
+	if b.tokens > float64(burst) {
+		b.tokens = float64(burst)
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Len return tracked buckets count
+func (l *Limiter) Len() int {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	return len(l.buckets)
+}
+
+// Cleanup remove buckets not used since idle duration
+func (l *Limiter) Cleanup(idle time.Duration) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	limit := time.Now().Add(-idle)
+	for key, b := range l.buckets {
+		if b.last.Before(limit) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Start periodic cleanup of idle buckets
+func (l *Limiter) Start(ctx context.Context) {
+	for {
+		select {
+		case <-time.After(DefaultCleanupDelay):
+			l.Cleanup(DefaultCleanupDelay)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
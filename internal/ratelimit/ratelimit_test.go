@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	limiter := New()
+
+	// burst is available immediately
+	for i := 0; i < 3; i++ {
+		if !limiter.Allow("foo", 1, 3) {
+			t.Fatalf("Allow() = false, want true for request %d", i)
+		}
+	}
+	if limiter.Allow("foo", 1, 3) {
+		t.Errorf("Allow() = true, want false when burst is consumed")
+	}
+
+	// keys are independent
+	if !limiter.Allow("bar", 1, 3) {
+		t.Errorf("Allow() = false, want true for another key")
+	}
+
+	// no limit
+	for i := 0; i < 10; i++ {
+		if !limiter.Allow("baz", 0, 0) {
+			t.Fatalf("Allow() = false, want true without rate")
+		}
+	}
+
+	// refill
+	if !limiter.Allow("fast", 100, 1) {
+		t.Fatalf("Allow() = false, want true")
+	}
+	<-time.After(50 * time.Millisecond)
+	if !limiter.Allow("fast", 100, 1) {
+		t.Errorf("Allow() = false, want true after refill")
+	}
+}
+
+func TestLimiter_Cleanup(t *testing.T) {
+	limiter := New()
+	limiter.Allow("foo", 1, 1)
+	limiter.Allow("bar", 1, 1)
+
+	limiter.Cleanup(time.Hour)
+	if limiter.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", limiter.Len())
+	}
+	limiter.Cleanup(0)
+	if limiter.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", limiter.Len())
+	}
+}
@@ -33,6 +33,8 @@ func startNatsServer(ctx context.Context, ipcSubject, natsHost string, natsPort
 	ns, err := server.NewServer(&server.Options{
 		Host: natsHost,
 		Port: natsPort,
+		// directory snapshots are exchanged for state sync
+		MaxPayload: 8 * 1024 * 1024,
 	})
 	if err != nil {
 		log.WithError(err).Fatal("Failed to start embedded nats")
@@ -3,6 +3,7 @@ package ipc
 import (
 	"bufio"
 	"context"
+	"os"
 	"os/exec"
 	"strings"
 	"time"
@@ -10,7 +11,13 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-func StartProcessDaemon(ctx context.Context, name, process string, args ...string) {
+// SecretsFd is the file descriptor of secrets pipe inherited by child process
+const SecretsFd = 3
+
+// StartProcessDaemon start process and restart it when exited.
+// Secrets are written to an inherited pipe (SecretsFd) on each start, if not empty.
+// Process environment is env, secrets must not be inherited from environment.
+func StartProcessDaemon(ctx context.Context, name, process string, secrets []byte, env []string, args ...string) {
 	for {
 		select {
 		case <-ctx.Done():
@@ -18,13 +25,14 @@ func StartProcessDaemon(ctx context.Context, name, process string, args ...strin
 			log.Trace("Starting process")
 
 			<-time.After(3 * time.Second)
-			startSubProcess(ctx, name, process, args...)
+			startSubProcess(ctx, name, process, secrets, env, args...)
 		}
 	}
 }
 
-func startSubProcess(ctx context.Context, name, process string, args ...string) {
+func startSubProcess(ctx context.Context, name, process string, secrets []byte, env []string, args ...string) {
 	cmd := exec.Command(process, args...)
+	cmd.Env = env
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		log.WithError(err).Fatal("Failed to get process stdout")
@@ -32,10 +40,32 @@ func startSubProcess(ctx context.Context, name, process string, args ...string)
 	cmd.Stderr = cmd.Stdout
 	defer stdout.Close()
 
+	// secrets are not visible in process arguments
+	var secretsWriter *os.File
+	if len(secrets) > 0 {
+		reader, writer, err := os.Pipe()
+		if err != nil {
+			log.WithError(err).Fatal("Failed to create secrets pipe")
+		}
+		cmd.ExtraFiles = []*os.File{reader}
+		secretsWriter = writer
+	}
+
 	if err := cmd.Start(); err != nil {
 		log.WithError(err).Fatal("Failed to start sub process")
 	}
 
+	if secretsWriter != nil {
+		// read end is owned by child process
+		cmd.ExtraFiles[0].Close()
+		go func() {
+			defer secretsWriter.Close()
+			if _, err := secretsWriter.Write(secrets); err != nil {
+				log.WithError(err).Error("Failed to write secrets")
+			}
+		}()
+	}
+
 	scanner := bufio.NewScanner(stdout)
 	for scanner.Scan() {
 		text := strings.Trim(scanner.Text(), "\n\r ")
@@ -21,4 +21,8 @@ const (
 	MessageTypeSoroban MessageType = "soroban"
 	MessageTypeP2P     MessageType = "p2p"
 	MessageTypeIPC     MessageType = "ipc"
+	MessageTypeSync    MessageType = "sync"
+	MessageTypeStatus  MessageType = "status"
+	MessageTypeLookup  MessageType = "lookup"
+	MessageTypeRuleset MessageType = "ruleset"
 )
@@ -1,7 +1,9 @@
 package soroban
 
 import (
+	"encoding/json"
 	"os"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 )
@@ -11,21 +13,51 @@ var (
 		LogLevel: "info",
 		LogFile:  "-",
 		Soroban: SorobanInfo{
-			Config:        "",
-			Confidential:  "",
-			Domain:        "samourai",
-			DirectoryType: "default",
-			WithTor:       false,
-			Seed:          "",
-			Hostname:      "localhost",
-			Port:          4242,
+			Config:                     "",
+			Confidential:               "",
+			ConfidentialAdmin:          "",
+			ConfidentialAdminAlgorithm: "",
+			ConfidentialRuleset:        "",
+			Domain:                     "samourai",
+			DirectoryType:              "default",
+			WithTor:                    false,
+			Seed:                       "",
+			SeedFile:                   "",
+			Hostname:                   "localhost",
+			Port:                       4242,
 		},
 		P2P: P2PInfo{
-			Seed:       "",
-			Bootstrap:  "",
-			Hostname:   "",
-			ListenPort: 1042,
-			Room:       "samourai-p2p",
+			Seed:            "",
+			SeedFile:        "",
+			KeyFile:         "",
+			Bootstrap:       nil,
+			Hostname:        "",
+			ListenPort:      1042,
+			Room:            "samourai-p2p",
+			MinPeers:        0,
+			PeerBook:        "",
+			BanList:         "",
+			Security:        "",
+			Encoding:        "",
+			ExitTimeout:     0,
+			LegacyHeartbeat: true,
+			Score: ScoreInfo{
+				Disabled:             false,
+				InvalidMessageWeight: -10,
+				MessageRate:          50,
+				MessageBurst:         200,
+				MessageRateWeight:    -1,
+				ColocationWeight:     -5,
+				ColocationThreshold:  3,
+				GossipThreshold:      -100,
+				PublishThreshold:     -500,
+				GraylistThreshold:    -1000,
+			},
+			Queue: QueueInfo{
+				Size:       1024,
+				Workers:    4,
+				DropPolicy: "oldest",
+			},
 		},
 		IPC: IPCInfo{
 			Subject:           "ipc.server",
@@ -51,6 +83,8 @@ func (p *Options) Load(config string) {
 	}
 	if data, err := os.ReadFile(config); err == nil {
 		var o Options
+		// options enabled by default are kept when missing from config
+		o.P2P.LegacyHeartbeat = p.P2P.LegacyHeartbeat
 		if err := o.parse(data); err == nil {
 			p.Merge(o)
 		}
@@ -76,14 +110,18 @@ func (p *Options) Merge(o Options) {
 }
 
 type SorobanInfo struct {
-	Config        string
-	Confidential  string
-	Domain        string
-	DirectoryType string
-	WithTor       bool
-	Seed          string
-	Hostname      string
-	Port          int
+	Config                     string
+	Confidential               string
+	ConfidentialAdmin          string
+	ConfidentialAdminAlgorithm string
+	ConfidentialRuleset        string
+	Domain                     string
+	DirectoryType              string
+	WithTor                    bool
+	Seed                       string
+	SeedFile                   string
+	Hostname                   string
+	Port                       int
 }
 
 func (p *SorobanInfo) Merge(s SorobanInfo) {
@@ -93,6 +131,15 @@ func (p *SorobanInfo) Merge(s SorobanInfo) {
 	if len(s.Confidential) > 0 {
 		p.Confidential = s.Confidential
 	}
+	if len(s.ConfidentialAdmin) > 0 {
+		p.ConfidentialAdmin = s.ConfidentialAdmin
+	}
+	if len(s.ConfidentialAdminAlgorithm) > 0 {
+		p.ConfidentialAdminAlgorithm = s.ConfidentialAdminAlgorithm
+	}
+	if len(s.ConfidentialRuleset) > 0 {
+		p.ConfidentialRuleset = s.ConfidentialRuleset
+	}
 	if len(s.Domain) > 0 {
 		p.Domain = s.Domain
 	}
@@ -105,6 +152,9 @@ func (p *SorobanInfo) Merge(s SorobanInfo) {
 	if len(s.Seed) > 0 {
 		p.Seed = s.Seed
 	}
+	if len(s.SeedFile) > 0 {
+		p.SeedFile = s.SeedFile
+	}
 	if len(s.Hostname) > 0 {
 		p.Hostname = s.Hostname
 	}
@@ -114,17 +164,187 @@ func (p *SorobanInfo) Merge(s SorobanInfo) {
 }
 
 type P2PInfo struct {
-	Seed       string
-	Bootstrap  string
-	Hostname   string
-	ListenPort int
-	Room       string
+	Seed            string
+	SeedFile        string
+	KeyFile         string
+	Bootstrap       BootstrapList
+	Hostname        string
+	ListenPort      int
+	Room            string
+	MinPeers        int
+	Rooms           []RoomInfo
+	Replication     []ReplicationInfo
+	PeerBook        string
+	BanList         string
+	Security        string
+	Encoding        string
+	ExitTimeout     int
+	LegacyHeartbeat bool
+	Score           ScoreInfo
+	Queue           QueueInfo
+}
+
+// ScoreInfo configure gossipsub peer scoring.
+// Weights are negative, penalties are squared counters for invalid messages and colocation.
+type ScoreInfo struct {
+	Disabled             bool
+	InvalidMessageWeight float64
+	MessageRate          float64
+	MessageBurst         int
+	MessageRateWeight    float64
+	ColocationWeight     float64
+	ColocationThreshold  int
+	GossipThreshold      float64
+	PublishThreshold     float64
+	GraylistThreshold    float64
+}
+
+func (p *ScoreInfo) Merge(i ScoreInfo) {
+	if i.Disabled {
+		p.Disabled = i.Disabled
+	}
+	if i.InvalidMessageWeight != 0 {
+		p.InvalidMessageWeight = i.InvalidMessageWeight
+	}
+	if i.MessageRate > 0 {
+		p.MessageRate = i.MessageRate
+	}
+	if i.MessageBurst > 0 {
+		p.MessageBurst = i.MessageBurst
+	}
+	if i.MessageRateWeight != 0 {
+		p.MessageRateWeight = i.MessageRateWeight
+	}
+	if i.ColocationWeight != 0 {
+		p.ColocationWeight = i.ColocationWeight
+	}
+	if i.ColocationThreshold > 0 {
+		p.ColocationThreshold = i.ColocationThreshold
+	}
+	if i.GossipThreshold != 0 {
+		p.GossipThreshold = i.GossipThreshold
+	}
+	if i.PublishThreshold != 0 {
+		p.PublishThreshold = i.PublishThreshold
+	}
+	if i.GraylistThreshold != 0 {
+		p.GraylistThreshold = i.GraylistThreshold
+	}
+}
+
+// QueueInfo configure inbound p2p message queue.
+// DropPolicy is `oldest` or `newest`, the message dropped when queue is full.
+type QueueInfo struct {
+	Size       int
+	Workers    int
+	DropPolicy string
+}
+
+func (p *QueueInfo) Merge(i QueueInfo) {
+	if i.Size > 0 {
+		p.Size = i.Size
+	}
+	if i.Workers > 0 {
+		p.Workers = i.Workers
+	}
+	if len(i.DropPolicy) > 0 {
+		p.DropPolicy = i.DropPolicy
+	}
+}
+
+// ReplicationInfo is the replication policy of keys matching prefix, first matching prefix applies.
+// Policy is `local` (never leave the node), `room` (room matching key prefix) or a room name.
+type ReplicationInfo struct {
+	Prefix string
+	Policy string
+}
+
+// RoomInfo is an additional p2p room, replicating keys matching prefixes.
+// Writes wait for MinPeers mesh peers before publishing, if set.
+type RoomInfo struct {
+	Name      string
+	Prefixes  []string
+	Bootstrap BootstrapList
+	MinPeers  int `json:",omitempty"`
+}
+
+// BootstrapList is a list of bootstrap multiaddrs, from a yaml or json list or a comma separated string
+type BootstrapList []string
+
+// ParseBootstrapList split comma separated multiaddrs
+func ParseBootstrapList(value string) BootstrapList {
+	var result BootstrapList
+	for _, addr := range strings.Split(value, ",") {
+		addr = strings.TrimSpace(addr)
+		if len(addr) == 0 {
+			continue
+		}
+		result = append(result, addr)
+	}
+	return result
+}
+
+func (p BootstrapList) String() string {
+	return strings.Join(p, ",")
+}
+
+// Set implements flag.Value
+func (p *BootstrapList) Set(value string) error {
+	*p = ParseBootstrapList(value)
+	return nil
+}
+
+func (p *BootstrapList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		*p = list
+		return nil
+	}
+	var value string
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	*p = ParseBootstrapList(value)
+	return nil
+}
+
+func (p *BootstrapList) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*p = list
+		return nil
+	}
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*p = ParseBootstrapList(value)
+	return nil
+}
+
+// HasBootstrap return true if default room or any room has a bootstrap
+func (p *P2PInfo) HasBootstrap() bool {
+	if len(p.Bootstrap) > 0 {
+		return true
+	}
+	for _, room := range p.Rooms {
+		if len(room.Bootstrap) > 0 {
+			return true
+		}
+	}
+	return false
 }
 
 func (p *P2PInfo) Merge(i P2PInfo) {
 	if len(i.Seed) > 0 {
 		p.Seed = i.Seed
 	}
+	if len(i.SeedFile) > 0 {
+		p.SeedFile = i.SeedFile
+	}
+	if len(i.KeyFile) > 0 {
+		p.KeyFile = i.KeyFile
+	}
 	if len(i.Bootstrap) > 0 {
 		p.Bootstrap = i.Bootstrap
 	}
@@ -137,6 +357,34 @@ func (p *P2PInfo) Merge(i P2PInfo) {
 	if len(i.Room) > 0 {
 		p.Room = i.Room
 	}
+	if i.MinPeers > 0 {
+		p.MinPeers = i.MinPeers
+	}
+	if len(i.Rooms) > 0 {
+		p.Rooms = i.Rooms
+	}
+	if len(i.Replication) > 0 {
+		p.Replication = i.Replication
+	}
+	if len(i.PeerBook) > 0 {
+		p.PeerBook = i.PeerBook
+	}
+	if len(i.BanList) > 0 {
+		p.BanList = i.BanList
+	}
+	if len(i.Security) > 0 {
+		p.Security = i.Security
+	}
+	if len(i.Encoding) > 0 {
+		p.Encoding = i.Encoding
+	}
+	if i.ExitTimeout > 0 {
+		p.ExitTimeout = i.ExitTimeout
+	}
+	// enabled by default, set from defaults before parsing config
+	p.LegacyHeartbeat = i.LegacyHeartbeat
+	p.Score.Merge(i.Score)
+	p.Queue.Merge(i.Queue)
 }
 
 type IPCInfo struct {
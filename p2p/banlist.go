@@ -0,0 +1,283 @@
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/control"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// banListReloadDelay between ban list file checks
+	banListReloadDelay = 30 * time.Second
+	// blacklistDuration for peers sending invalid messages
+	blacklistDuration = time.Hour
+)
+
+// BanEntry is a peer banned by an admin
+type BanEntry struct {
+	PeerID string
+	Reason string `json:",omitempty"`
+	Date   time.Time
+}
+
+// BanList keep peers banned by admin on disk, and peers blacklisted temporarily for misbehaving.
+// It is used as gossipsub blacklist and libp2p connection gater.
+type BanList struct {
+	filename string
+
+	mutex     sync.Mutex
+	banned    map[peer.ID]BanEntry
+	temporary map[peer.ID]time.Time
+	modTime   time.Time
+	onBan     func(id peer.ID)
+}
+
+// LoadBanList read ban list from file, missing file is an empty ban list.
+// Ban list is kept in memory only if filename is empty.
+func LoadBanList(filename string) (*BanList, error) {
+	result := BanList{
+		filename:  filename,
+		banned:    make(map[peer.ID]BanEntry),
+		temporary: make(map[peer.ID]time.Time),
+	}
+	if err := result.reload(); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (p *BanList) reload() error {
+	if len(p.filename) == 0 {
+		return nil
+	}
+	info, err := os.Stat(p.filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	p.mutex.Lock()
+	changed := !info.ModTime().Equal(p.modTime)
+	p.mutex.Unlock()
+	if !changed {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(p.filename)
+	if err != nil {
+		return err
+	}
+	var entries []BanEntry
+	err = json.Unmarshal(data, &entries)
+	if err != nil {
+		return err
+	}
+
+	banned := make(map[peer.ID]BanEntry)
+	for _, entry := range entries {
+		id, err := peer.Decode(entry.PeerID)
+		if err != nil {
+			continue
+		}
+		banned[id] = entry
+	}
+
+	p.mutex.Lock()
+	p.banned = banned
+	p.modTime = info.ModTime()
+	onBan := p.onBan
+	p.mutex.Unlock()
+
+	if onBan != nil {
+		for id := range banned {
+			onBan(id)
+		}
+	}
+	return nil
+}
+
+// save must be called with mutex locked
+func (p *BanList) save() error {
+	if len(p.filename) == 0 {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(p.entries(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(p.filename), ".banlist-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	err = os.Rename(tmp.Name(), p.filename)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(p.filename); err == nil {
+		p.modTime = info.ModTime()
+	}
+	return nil
+}
+
+// entries must be called with mutex locked
+func (p *BanList) entries() []BanEntry {
+	result := make([]BanEntry, 0, len(p.banned))
+	for _, entry := range p.banned {
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PeerID < result[j].PeerID
+	})
+	return result
+}
+
+// Entries return peers banned by admin
+func (p *BanList) Entries() []BanEntry {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.entries()
+}
+
+// Ban peer permanently and save ban list
+func (p *BanList) Ban(peerID, reason string) error {
+	id, err := peer.Decode(peerID)
+	if err != nil {
+		return err
+	}
+
+	p.mutex.Lock()
+	p.banned[id] = BanEntry{
+		PeerID: id.String(),
+		Reason: reason,
+		Date:   time.Now().UTC(),
+	}
+	err = p.save()
+	onBan := p.onBan
+	p.mutex.Unlock()
+
+	if onBan != nil {
+		onBan(id)
+	}
+	return err
+}
+
+// Unban peer and save ban list
+func (p *BanList) Unban(peerID string) error {
+	id, err := peer.Decode(peerID)
+	if err != nil {
+		return err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if _, ok := p.banned[id]; !ok {
+		return errors.New("peer not banned")
+	}
+	delete(p.banned, id)
+	delete(p.temporary, id)
+	return p.save()
+}
+
+// Add blacklist peer temporarily, implements pubsub.Blacklist
+func (p *BanList) Add(id peer.ID) bool {
+	p.mutex.Lock()
+	p.temporary[id] = time.Now().Add(blacklistDuration)
+	onBan := p.onBan
+	p.mutex.Unlock()
+
+	if onBan != nil {
+		onBan(id)
+	}
+	return true
+}
+
+// Contains return true if peer is banned or blacklisted, implements pubsub.Blacklist
+func (p *BanList) Contains(id peer.ID) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if _, ok := p.banned[id]; ok {
+		return true
+	}
+	if until, ok := p.temporary[id]; ok {
+		if time.Now().Before(until) {
+			return true
+		}
+		delete(p.temporary, id)
+	}
+	return false
+}
+
+// Start reload ban list file periodically, to apply bans from other processes
+func (p *BanList) Start(ctx context.Context) {
+	if len(p.filename) == 0 {
+		return // Noop
+	}
+	for {
+		select {
+		case <-time.After(banListReloadDelay):
+			if err := p.reload(); err != nil {
+				log.WithError(err).WithField("Filename", p.filename).Error("Failed to reload ban list")
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *BanList) setOnBan(onBan func(id peer.ID)) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.onBan = onBan
+}
+
+// connection gater, refuse connections with banned peers
+
+func (p *BanList) InterceptPeerDial(id peer.ID) bool {
+	return !p.Contains(id)
+}
+
+func (p *BanList) InterceptAddrDial(id peer.ID, addr multiaddr.Multiaddr) bool {
+	return !p.Contains(id)
+}
+
+func (p *BanList) InterceptAccept(network.ConnMultiaddrs) bool {
+	return true
+}
+
+func (p *BanList) InterceptSecured(dir network.Direction, id peer.ID, addrs network.ConnMultiaddrs) bool {
+	return !p.Contains(id)
+}
+
+func (p *BanList) InterceptUpgraded(network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
@@ -0,0 +1,61 @@
+package p2p
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func newTestPeerID(t *testing.T) peer.ID {
+	_, pubKey, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPublicKey(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestBanList(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "banlist.json")
+	banned := newTestPeerID(t)
+	blacklisted := newTestPeerID(t)
+
+	list, err := LoadBanList(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := list.Ban(banned.String(), "spam"); err != nil {
+		t.Fatal(err)
+	}
+	list.Add(blacklisted)
+	if !list.Contains(banned) || !list.Contains(blacklisted) {
+		t.Fatal("Contains() expected banned peers")
+	}
+	if err := list.Ban("invalid", ""); err == nil {
+		t.Error("Ban() expected error for invalid peer ID")
+	}
+
+	// only admin bans are persisted
+	list, err = LoadBanList(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !list.Contains(banned) || list.Contains(blacklisted) {
+		t.Fatal("LoadBanList() expected admin bans only")
+	}
+	if entries := list.Entries(); len(entries) != 1 || entries[0].Reason != "spam" {
+		t.Errorf("Entries() = %v", entries)
+	}
+
+	if err := list.Unban(banned.String()); err != nil {
+		t.Fatal(err)
+	}
+	if list.Contains(banned) {
+		t.Error("Unban() peer still banned")
+	}
+}
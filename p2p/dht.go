@@ -2,16 +2,20 @@ package p2p
 
 import (
 	"context"
-	"log"
 	"sync"
+	"sync/atomic"
 
 	dht "github.com/libp2p/go-libp2p-kad-dht"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/multiformats/go-multiaddr"
+
+	log "github.com/sirupsen/logrus"
 )
 
-func NewDHT(ctx context.Context, host host.Host, bootstrapPeers ...multiaddr.Multiaddr) (*dht.IpfsDHT, error) {
+// NewDHT create DHT and connect concurrently to bootstrap peers and known peers.
+// Invalid bootstrap addresses are skipped, the DHT is returned even if no peer is reachable.
+func NewDHT(ctx context.Context, host host.Host, bootstrapPeers []multiaddr.Multiaddr, knownPeers []peer.AddrInfo) (*dht.IpfsDHT, error) {
 	var options []dht.Option
 
 	// if no bootstrap peers give this peer act as a bootstraping node
@@ -29,24 +33,48 @@ func NewDHT(ctx context.Context, host host.Host, bootstrapPeers ...multiaddr.Mul
 		return nil, err
 	}
 
-	var wg sync.WaitGroup
+	peers := append(bootstrapAddrInfos(bootstrapPeers), knownPeers...)
+	if len(peers) > 0 && connectPeers(ctx, host, peers) == 0 {
+		log.Warning("No bootstrap node reachable")
+	}
+
+	return kdht, nil
+}
+
+// bootstrapAddrInfos return peers of bootstrap addresses, invalid addresses are skipped
+func bootstrapAddrInfos(bootstrapPeers []multiaddr.Multiaddr) []peer.AddrInfo {
+	var result []peer.AddrInfo
 	for _, peerAddr := range bootstrapPeers {
 		peerinfo, err := peer.AddrInfoFromP2pAddr(peerAddr)
 		if err != nil {
-			panic(err)
+			log.WithError(err).WithField("Addr", peerAddr.String()).Error("Invalid bootstrap address")
+			continue
+		}
+		result = append(result, *peerinfo)
+	}
+	return result
+}
+
+// connectPeers connect concurrently to peers, return count of connected peers
+func connectPeers(ctx context.Context, host host.Host, peers []peer.AddrInfo) int {
+	var connected int32
+	var wg sync.WaitGroup
+	for _, peerinfo := range peers {
+		if peerinfo.ID == host.ID() {
+			continue
 		}
 
 		wg.Add(1)
-		go func() {
+		go func(peerinfo peer.AddrInfo) {
 			defer wg.Done()
-			if err := host.Connect(ctx, *peerinfo); err != nil {
-				log.Printf("Error while connecting to node %q: %-v", peerinfo, err)
-			} else {
-				log.Printf("Connection established with bootstrap node: %q", *peerinfo)
+			if err := host.Connect(ctx, peerinfo); err != nil {
+				log.WithError(err).WithField("PeerID", peerinfo.ID).Warning("Failed to connect to bootstrap node")
+				return
 			}
-		}()
+			atomic.AddInt32(&connected, 1)
+			log.WithField("PeerID", peerinfo.ID).Info("Connection established with bootstrap node")
+		}(peerinfo)
 	}
 	wg.Wait()
-
-	return kdht, nil
+	return int(connected)
 }
@@ -13,12 +13,14 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-func Discover(ctx context.Context, h host.Host, dht *dht.IpfsDHT, rendezvous string, ready chan struct{}) {
+// Discover advertise and find peers of rendezvous, connected peers are added to peer book if not nil
+func Discover(ctx context.Context, h host.Host, dht *dht.IpfsDHT, rendezvous string, peerBook *PeerBook, ready chan struct{}) {
 	var routingDiscovery = routing.NewRoutingDiscovery(dht)
 
 	err := advertize(ctx, routingDiscovery, rendezvous, 3)
 	if err != nil {
-		log.WithError(err).Panic("Advertise failed, giving up")
+		// advertize daemon retries later
+		log.WithError(err).Error("Advertise failed")
 	}
 
 	// advertize daemon
@@ -68,6 +70,9 @@ func Discover(ctx context.Context, h host.Host, dht *dht.IpfsDHT, rendezvous str
 					}
 					newPeersCount++
 				}
+				if peerBook != nil {
+					peerBook.Add(p)
+				}
 			}
 			if newPeersCount > 0 {
 				log.WithField("Count", newPeersCount).Info("Connected to new peers")
@@ -79,7 +84,7 @@ func Discover(ctx context.Context, h host.Host, dht *dht.IpfsDHT, rendezvous str
 func advertize(ctx context.Context, routingDiscovery *routing.RoutingDiscovery, rendezvous string, retry int) error {
 	var err error
 	for i := 0; i < retry; i++ {
-		_, err := routingDiscovery.Advertise(ctx, rendezvous, discovery.TTL(15*time.Minute))
+		_, err = routingDiscovery.Advertise(ctx, rendezvous, discovery.TTL(15*time.Minute))
 		if err != nil {
 			log.WithError(err).Error("failed to Advertise")
 
@@ -0,0 +1,217 @@
+package p2p
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Message encodings, JSON is understood by all peers, binary envelope since EnvelopeVersion 1
+const (
+	EncodingJSON   = "json"
+	EncodingBinary = "binary"
+	// EncodingAuto use binary envelope when all room peers advertise it with liveness protocol
+	EncodingAuto = "auto"
+
+	// EnvelopeVersion is the latest binary envelope version, advertised to peers
+	EnvelopeVersion = 1
+
+	// envelopeMagic start binary envelopes, JSON messages always start with '{'
+	envelopeMagic = 0xb5
+	// envelopeHeaderSize is magic, version and flags
+	envelopeHeaderSize = 3
+	// envelopeCompressed flag, envelope body is deflated
+	envelopeCompressed = 0x01
+	// envelopeCompressThreshold is the minimum body size to try compression
+	envelopeCompressThreshold = 256
+	// envelopeMaxSize of decompressed body
+	envelopeMaxSize = 4 * 1024 * 1024
+)
+
+// Message fields of binary envelope
+const (
+	fieldID      protowire.Number = 1
+	fieldOrigin  protowire.Number = 2
+	fieldHops    protowire.Number = 3
+	fieldContext protowire.Number = 4
+	fieldPayload protowire.Number = 5
+)
+
+// ParseEncoding check message encoding, empty is auto
+func ParseEncoding(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case "":
+		return EncodingAuto, nil
+	case EncodingJSON, EncodingBinary, EncodingAuto:
+		return value, nil
+	default:
+		return "", fmt.Errorf("unknown message encoding %s", value)
+	}
+}
+
+// SetEncoding set encoding of published messages, must be called before Start
+func (p *P2P) SetEncoding(encoding string) {
+	p.encoding = encoding
+}
+
+// messageEncoding return encoding of published messages.
+// Auto mode use binary envelope only when all room peers support it, JSON otherwise.
+func (p *P2P) messageEncoding() string {
+	switch p.encoding {
+	case EncodingJSON, EncodingBinary:
+		return p.encoding
+	}
+
+	ids := p.livenessPeers()
+	if len(ids) == 0 {
+		return EncodingJSON
+	}
+
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	for _, id := range ids {
+		health, ok := p.health[id]
+		if !ok || health.Envelope < EnvelopeVersion {
+			return EncodingJSON
+		}
+	}
+	return EncodingBinary
+}
+
+// isEnvelope return true if data is a binary envelope
+func isEnvelope(data []byte) bool {
+	return len(data) >= envelopeHeaderSize && data[0] == envelopeMagic
+}
+
+// ToEnvelope encode message in versioned binary envelope, body is deflated when smaller
+func (p *Message) ToEnvelope() ([]byte, error) {
+	if len(p.Context) == 0 {
+		return nil, errors.New("invalid context")
+	}
+	if len(p.Payload) == 0 {
+		return nil, errors.New("invalid payload")
+	}
+
+	var body []byte
+	if len(p.ID) > 0 {
+		body = protowire.AppendTag(body, fieldID, protowire.BytesType)
+		body = protowire.AppendString(body, p.ID)
+	}
+	if len(p.Origin) > 0 {
+		body = protowire.AppendTag(body, fieldOrigin, protowire.BytesType)
+		body = protowire.AppendString(body, p.Origin)
+	}
+	if p.Hops > 0 {
+		body = protowire.AppendTag(body, fieldHops, protowire.VarintType)
+		body = protowire.AppendVarint(body, uint64(p.Hops))
+	}
+	body = protowire.AppendTag(body, fieldContext, protowire.BytesType)
+	body = protowire.AppendString(body, p.Context)
+	body = protowire.AppendTag(body, fieldPayload, protowire.BytesType)
+	body = protowire.AppendBytes(body, p.Payload)
+
+	var flags byte
+	if len(body) >= envelopeCompressThreshold {
+		compressed, err := deflate(body)
+		if err != nil {
+			return nil, err
+		}
+		if len(compressed) < len(body) {
+			body = compressed
+			flags |= envelopeCompressed
+		}
+	}
+
+	result := make([]byte, 0, envelopeHeaderSize+len(body))
+	result = append(result, envelopeMagic, EnvelopeVersion, flags)
+	return append(result, body...), nil
+}
+
+// messageFromEnvelope decode binary envelope, unknown fields are skipped
+func messageFromEnvelope(data []byte) (Message, error) {
+	if !isEnvelope(data) {
+		return Message{}, errors.New("invalid envelope")
+	}
+	version, flags, body := data[1], data[2], data[envelopeHeaderSize:]
+	if version == 0 || version > EnvelopeVersion {
+		return Message{}, fmt.Errorf("unsupported envelope version %d", version)
+	}
+	if flags&envelopeCompressed != 0 {
+		var err error
+		body, err = inflate(body)
+		if err != nil {
+			return Message{}, err
+		}
+	}
+
+	var result Message
+	for len(body) > 0 {
+		num, typ, n := protowire.ConsumeTag(body)
+		if n < 0 {
+			return Message{}, protowire.ParseError(n)
+		}
+		body = body[n:]
+
+		switch {
+		case num == fieldID && typ == protowire.BytesType:
+			result.ID, n = protowire.ConsumeString(body)
+		case num == fieldOrigin && typ == protowire.BytesType:
+			result.Origin, n = protowire.ConsumeString(body)
+		case num == fieldContext && typ == protowire.BytesType:
+			result.Context, n = protowire.ConsumeString(body)
+		case num == fieldPayload && typ == protowire.BytesType:
+			var payload []byte
+			payload, n = protowire.ConsumeBytes(body)
+			result.Payload = append([]byte{}, payload...)
+		case num == fieldHops && typ == protowire.VarintType:
+			var hops uint64
+			hops, n = protowire.ConsumeVarint(body)
+			if hops > MaxHops {
+				return Message{}, errors.New("too many hops")
+			}
+			result.Hops = int(hops)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, body)
+		}
+		if n < 0 {
+			return Message{}, protowire.ParseError(n)
+		}
+		body = body[n:]
+	}
+	return result, nil
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func inflate(data []byte) ([]byte, error) {
+	reader := flate.NewReader(bytes.NewReader(data))
+	defer reader.Close()
+
+	result, err := io.ReadAll(io.LimitReader(reader, envelopeMaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(result) > envelopeMaxSize {
+		return nil, errors.New("envelope too large")
+	}
+	return result, nil
+}
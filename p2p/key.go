@@ -1,12 +1,28 @@
 package p2p
 
 import (
+	"crypto/ed25519"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"strconv"
 
+	"github.com/cretz/bine/torutil"
+	bineed25519 "github.com/cretz/bine/torutil/ed25519"
 	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// childSeedSalt is the HKDF salt used to derive child seeds from master seed
+	childSeedSalt = "soroban.p2p.child"
 )
 
 func keyExists(filename string) bool {
@@ -15,7 +31,7 @@ func keyExists(filename string) bool {
 }
 
 func keySave(filename string, key crypto.PrivKey) error {
-	file, err := os.Create(filename)
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
 	if err != nil {
 		return err
 	}
@@ -52,6 +68,7 @@ func keyLoad(filename string) (crypto.PrivKey, error) {
 	return crypto.UnmarshalSecp256k1PrivateKey(data)
 }
 
+// KeyLoadOrCreate load secp256k1 private key from file, or create a new key and save it
 func KeyLoadOrCreate(filename string) (priv crypto.PrivKey, err error) {
 	if keyExists(filename) {
 		priv, err = keyLoad(filename)
@@ -70,3 +87,77 @@ func KeyLoadOrCreate(filename string) (priv crypto.PrivKey, err error) {
 
 	return
 }
+
+// LoadIdentity return p2p private key from key file if set, or from hex seed.
+// Seed `auto` generate an ephemeral key, a new peer ID and onion address on each start.
+func LoadIdentity(p2pSeed, keyFile string) (crypto.PrivKey, error) {
+	if len(keyFile) > 0 {
+		return KeyLoadOrCreate(keyFile)
+	}
+
+	switch p2pSeed {
+	case "":
+		return nil, errors.New("no p2p identity")
+
+	case "auto":
+		priv, _, err := crypto.GenerateKeyPairWithReader(crypto.Secp256k1, 32, rand.Reader)
+		return priv, err
+
+	default:
+		data, err := hex.DecodeString(p2pSeed)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.UnmarshalSecp256k1PrivateKey(data)
+	}
+}
+
+// onionKey return ed25519 onion service key, derived from p2p private key
+func onionKey(priv crypto.PrivKey) (ed25519.PrivateKey, error) {
+	data, err := priv.Raw()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != ed25519.SeedSize {
+		return nil, errors.New("invalid p2p private key size")
+	}
+	return ed25519.NewKeyFromSeed(data), nil
+}
+
+// DeriveChildSeed derive p2p seed of IPC child from master seed, with HKDF-SHA256 and child ID as info.
+// Children get distinct and stable identities, `auto` seed stays ephemeral.
+func DeriveChildSeed(p2pSeed string, childID int) (string, error) {
+	if len(p2pSeed) == 0 || p2pSeed == "auto" {
+		return p2pSeed, nil
+	}
+	master, err := hex.DecodeString(p2pSeed)
+	if err != nil {
+		return "", err
+	}
+
+	reader := hkdf.New(sha256.New, master, []byte(childSeedSalt), []byte(strconv.Itoa(childID)))
+	data := make([]byte, 32)
+	// skip the unlikely keys out of secp256k1 range
+	for {
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return "", err
+		}
+		if _, err := crypto.UnmarshalSecp256k1PrivateKey(data); err == nil {
+			return hex.EncodeToString(data), nil
+		}
+	}
+}
+
+// IdentityAddr return onion multiaddr of p2p identity, with peer ID
+func IdentityAddr(priv crypto.PrivKey, listenPort int) (multiaddr.Multiaddr, error) {
+	key, err := onionKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	onionID := torutil.OnionServiceIDFromPrivateKey(bineed25519.FromCryptoPrivateKey(key))
+	return multiaddr.NewMultiaddr(fmt.Sprintf("/onion3/%s:%d/p2p/%s", onionID, listenPort, id))
+}
@@ -0,0 +1,115 @@
+package p2p
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadIdentity(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "p2p.key")
+
+	created, err := LoadIdentity("auto", keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("key file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// key file is stable across restarts
+	loaded, err := LoadIdentity("", keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created.Equals(loaded) {
+		t.Error("LoadIdentity() expected same key from key file")
+	}
+
+	// auto is ephemeral
+	first, err := LoadIdentity("auto", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := LoadIdentity("auto", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Equals(second) {
+		t.Error("LoadIdentity() expected new key with auto")
+	}
+
+	if _, err := LoadIdentity("", ""); err == nil {
+		t.Error("LoadIdentity() expected error without seed nor key file")
+	}
+}
+
+func TestOnionKey(t *testing.T) {
+	seed := "c2d0b9870b89b10a47aa7e33fd3b51dc86eaa160d764e3b16ad3924356cc84d9"
+	priv, err := LoadIdentity(seed, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := onionKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := onionKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.Equal(second) {
+		t.Error("onionKey() expected deterministic key")
+	}
+}
+
+func TestDeriveChildSeed(t *testing.T) {
+	master := "c2d0b9870b89b10a47aa7e33fd3b51dc86eaa160d764e3b16ad3924356cc84d9"
+
+	first, err := DeriveChildSeed(master, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := DeriveChildSeed(master, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := DeriveChildSeed(master, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != again {
+		t.Error("DeriveChildSeed() expected stable seed")
+	}
+	if first == second || first == master {
+		t.Error("DeriveChildSeed() expected distinct seeds")
+	}
+
+	// distinct peer IDs and onion addresses
+	addrs := make(map[string]bool)
+	for _, seed := range []string{master, first, second} {
+		priv, err := LoadIdentity(seed, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr, err := IdentityAddr(priv, 1042)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addrs[addr.String()] = true
+	}
+	if len(addrs) != 3 {
+		t.Errorf("IdentityAddr() expected distinct addresses, got %v", addrs)
+	}
+
+	if seed, _ := DeriveChildSeed("auto", 1); seed != "auto" {
+		t.Errorf("DeriveChildSeed(auto) = %s", seed)
+	}
+	if _, err := DeriveChildSeed("invalid", 1); err == nil {
+		t.Error("DeriveChildSeed() expected error for invalid seed")
+	}
+}
@@ -0,0 +1,232 @@
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// LivenessProtocol is the request/response protocol used for heartbeats and peer health
+	LivenessProtocol = protocol.ID("/soroban/liveness/1.0.0")
+
+	livenessDelay         = 30 * time.Second
+	livenessStreamTimeout = 30 * time.Second
+	livenessMaxSize       = 1024
+	// livenessRetention of health of peers not seen anymore
+	livenessRetention = time.Hour
+)
+
+var (
+	// Version is the software version reported to peers
+	Version = "dev"
+)
+
+type livenessMessage struct {
+	Time    int64
+	Version string
+	// Envelope is the binary envelope version supported, zero for JSON only peers
+	Envelope int `json:",omitempty"`
+}
+
+// PeerHealth is measured by liveness protocol
+type PeerHealth struct {
+	RTT         time.Duration
+	ClockOffset time.Duration
+	Version     string
+	Envelope    int
+	LastSeen    time.Time
+	Failures    int
+}
+
+// HealthStats summarize health of peers
+type HealthStats struct {
+	Peers          int
+	MedianRTT      string
+	MaxClockOffset string
+	Versions       map[string]int
+}
+
+func (p *P2P) startLiveness(ctx context.Context, h host.Host) {
+	h.SetStreamHandler(LivenessProtocol, handleLiveness)
+
+	go func() {
+		ticker := time.NewTicker(livenessDelay)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.checkLiveness(ctx, h)
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// livenessPeers return peers of all rooms
+func (p *P2P) livenessPeers() []peer.ID {
+	ids := make(map[peer.ID]bool)
+	for _, room := range p.Rooms() {
+		for _, id := range p.roomPeers(room) {
+			ids[id] = true
+		}
+	}
+	result := make([]peer.ID, 0, len(ids))
+	for id := range ids {
+		result = append(result, id)
+	}
+	return result
+}
+
+// checkLiveness ping room peers concurrently, a response is a heartbeat
+func (p *P2P) checkLiveness(ctx context.Context, h host.Host) {
+	p.HeartbeatSent()
+
+	var wg sync.WaitGroup
+	for _, id := range p.livenessPeers() {
+		wg.Add(1)
+		go func(id peer.ID) {
+			defer wg.Done()
+
+			health, err := pingLiveness(ctx, h, id)
+			p.updateHealth(id, health, err)
+			if err != nil {
+				log.WithError(err).WithField("PeerID", id).Trace("p2p - liveness failed")
+				return
+			}
+			p.HeartbeatReceived(id.String())
+			log.WithField("PeerID", id).WithField("RTT", health.RTT).Trace("p2p - heartbeat received")
+		}(id)
+	}
+	wg.Wait()
+}
+
+func pingLiveness(ctx context.Context, h host.Host, id peer.ID) (PeerHealth, error) {
+	ctx, cancel := context.WithTimeout(ctx, livenessStreamTimeout)
+	defer cancel()
+
+	stream, err := h.NewStream(ctx, id, LivenessProtocol)
+	if err != nil {
+		return PeerHealth{}, err
+	}
+	defer stream.Close()
+	stream.SetDeadline(time.Now().Add(livenessStreamTimeout))
+
+	sent := time.Now()
+	err = json.NewEncoder(stream).Encode(livenessMessage{
+		Time:     sent.UnixNano(),
+		Version:  Version,
+		Envelope: EnvelopeVersion,
+	})
+	if err != nil {
+		stream.Reset()
+		return PeerHealth{}, err
+	}
+
+	var resp livenessMessage
+	err = json.NewDecoder(io.LimitReader(stream, livenessMaxSize)).Decode(&resp)
+	if err != nil {
+		stream.Reset()
+		return PeerHealth{}, err
+	}
+	received := time.Now()
+
+	rtt := received.Sub(sent)
+	return PeerHealth{
+		RTT:         rtt,
+		ClockOffset: time.Unix(0, resp.Time).Sub(sent.Add(rtt / 2)),
+		Version:     resp.Version,
+		Envelope:    resp.Envelope,
+		LastSeen:    received.UTC(),
+	}, nil
+}
+
+func handleLiveness(stream network.Stream) {
+	defer stream.Close()
+	stream.SetDeadline(time.Now().Add(livenessStreamTimeout))
+
+	var request livenessMessage
+	err := json.NewDecoder(io.LimitReader(stream, livenessMaxSize)).Decode(&request)
+	if err != nil {
+		stream.Reset()
+		return
+	}
+
+	err = json.NewEncoder(stream).Encode(livenessMessage{
+		Time:     time.Now().UnixNano(),
+		Version:  Version,
+		Envelope: EnvelopeVersion,
+	})
+	if err != nil {
+		stream.Reset()
+	}
+}
+
+func (p *P2P) updateHealth(id peer.ID, health PeerHealth, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.health == nil {
+		p.health = make(map[peer.ID]PeerHealth)
+	}
+	for key, value := range p.health {
+		if time.Since(value.LastSeen) > livenessRetention {
+			delete(p.health, key)
+		}
+	}
+
+	if err != nil {
+		current, ok := p.health[id]
+		if !ok {
+			return
+		}
+		current.Failures++
+		p.health[id] = current
+		return
+	}
+	p.health[id] = health
+}
+
+// healthStats must be called with mutex locked
+func (p *P2P) healthStats() *HealthStats {
+	if len(p.health) == 0 {
+		return nil
+	}
+
+	result := HealthStats{
+		Peers:    len(p.health),
+		Versions: make(map[string]int),
+	}
+	rtts := make([]time.Duration, 0, len(p.health))
+	var maxOffset time.Duration
+	for _, health := range p.health {
+		rtts = append(rtts, health.RTT)
+		offset := health.ClockOffset
+		if offset < 0 {
+			offset = -offset
+		}
+		if offset > maxOffset {
+			maxOffset = offset
+		}
+		result.Versions[health.Version]++
+	}
+	sort.Slice(rtts, func(i, j int) bool {
+		return rtts[i] < rtts[j]
+	})
+	result.MedianRTT = rtts[len(rtts)/2].String()
+	result.MaxClockOffset = maxOffset.String()
+	return &result
+}
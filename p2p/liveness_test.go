@@ -0,0 +1,44 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestLiveness(t *testing.T) {
+	dialer := newSecurityHost(t, DefaultSecurity)
+	remote := newSecurityHost(t, DefaultSecurity)
+	remote.SetStreamHandler(LivenessProtocol, handleLiveness)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := dialer.Connect(ctx, peer.AddrInfo{ID: remote.ID(), Addrs: remote.Addrs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	health, err := pingLiveness(ctx, dialer, remote.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.RTT <= 0 || health.Version != Version || health.LastSeen.IsZero() {
+		t.Errorf("pingLiveness() = %+v", health)
+	}
+	// same clock
+	if health.ClockOffset > time.Second || health.ClockOffset < -time.Second {
+		t.Errorf("pingLiveness() clock offset = %v", health.ClockOffset)
+	}
+
+	var p2P P2P
+	p2P.updateHealth(remote.ID(), health, nil)
+	p2P.updateHealth(remote.ID(), PeerHealth{}, context.DeadlineExceeded)
+	if p2P.health[remote.ID()].Failures != 1 {
+		t.Errorf("updateHealth() failures = %d", p2P.health[remote.ID()].Failures)
+	}
+	if stats := p2P.healthStats(); stats == nil || stats.Peers != 1 || stats.Versions[Version] != 1 {
+		t.Errorf("healthStats() = %+v", stats)
+	}
+}
@@ -0,0 +1,220 @@
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	soroban "code.samourai.io/wallet/samourai-soroban"
+	"code.samourai.io/wallet/samourai-soroban/confidential"
+	"code.samourai.io/wallet/samourai-soroban/internal/common"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// LookupProtocol is the request/response protocol used for federated lookups of a key
+	LookupProtocol = protocol.ID("/soroban/lookup/1.0.0")
+
+	lookupMaxPeers       = 3
+	lookupMaxRequestSize = 64 * 1024
+	lookupMaxSize        = 4 * 1024 * 1024
+	// lookupTimeout is shorter than IPC request timeout, lookups are forwarded by IPC server
+	lookupTimeout = 3 * time.Second
+)
+
+// LookupValidator check a federated lookup is allowed, request is the client request sent with lookup
+type LookupValidator func(name string, request []byte) error
+
+type lookupRequest struct {
+	Room    string
+	Name    string
+	Request json.RawMessage `json:",omitempty"`
+}
+
+type lookupResponse struct {
+	Items []soroban.DirectoryItem `json:",omitempty"`
+}
+
+// SetLookupValidator set validator of federated lookups received from peers, must be called before Start.
+// Lookups are refused if not set.
+func (p *P2P) SetLookupValidator(validator LookupValidator) {
+	p.lookupValidator = validator
+}
+
+func (p *P2P) startLookup(h host.Host) {
+	if p.sync == nil || p.lookupValidator == nil {
+		return // Noop
+	}
+	h.SetStreamHandler(LookupProtocol, p.handleLookup)
+}
+
+// Lookup ask room peers for items of directory name.
+// Answers are merged and deduplicated, keeping latest expiration date.
+// Answers don't carry signed requests, confidential or readonly keys are never looked up.
+func (p *P2P) Lookup(ctx context.Context, name string, request []byte) ([]soroban.DirectoryItem, error) {
+	if confidential.Protected(name) {
+		return nil, errors.New("key protected by confidential rules")
+	}
+
+	p.mutex.RLock()
+	host := p.host
+	p.mutex.RUnlock()
+	if host == nil {
+		return nil, ErrNotStarted
+	}
+
+	room := p.RoomFor(name)
+	if len(room) == 0 {
+		return nil, errors.New("key not replicated")
+	}
+	peers := p.roomPeers(room)
+	if len(peers) == 0 {
+		return nil, errors.New("no peer in room")
+	}
+	rand.Shuffle(len(peers), func(i, j int) {
+		peers[i], peers[j] = peers[j], peers[i]
+	})
+	if len(peers) > lookupMaxPeers {
+		peers = peers[:lookupMaxPeers]
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+
+	var mutex sync.Mutex
+	var answers [][]soroban.DirectoryItem
+	var wg sync.WaitGroup
+	for _, id := range peers {
+		wg.Add(1)
+		go func(id peer.ID) {
+			defer wg.Done()
+
+			items, err := lookupPeer(ctx, host, id, lookupRequest{
+				Room:    room,
+				Name:    name,
+				Request: request,
+			})
+			if err != nil {
+				log.WithError(err).WithField("PeerID", id).WithField("Name", name).Debug("Lookup failed")
+				return
+			}
+			mutex.Lock()
+			answers = append(answers, items)
+			mutex.Unlock()
+		}(id)
+	}
+	wg.Wait()
+
+	if len(answers) == 0 {
+		return nil, errors.New("no peer answered lookup")
+	}
+	return mergeItems(name, answers...), nil
+}
+
+// mergeItems deduplicate items of name by value, keeping latest expiration date.
+// Expiration dates are capped to the longest TTL.
+func mergeItems(name string, answers ...[]soroban.DirectoryItem) []soroban.DirectoryItem {
+	now := time.Now()
+	maxExpireOn := now.Add(common.MaxTimeToLive())
+	values := make(map[string]soroban.DirectoryItem)
+	for _, items := range answers {
+		for _, item := range items {
+			// peers only answer items of requested name
+			if item.Name != name || len(item.Value) == 0 || !item.ExpireOn.After(now) {
+				continue
+			}
+			if item.ExpireOn.After(maxExpireOn) {
+				item.ExpireOn = maxExpireOn
+			}
+			if current, ok := values[item.Value]; ok && !item.ExpireOn.After(current.ExpireOn) {
+				continue
+			}
+			values[item.Value] = item
+		}
+	}
+
+	result := make([]soroban.DirectoryItem, 0, len(values))
+	for _, item := range values {
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Value < result[j].Value
+	})
+	return result
+}
+
+func lookupPeer(ctx context.Context, h host.Host, id peer.ID, request lookupRequest) ([]soroban.DirectoryItem, error) {
+	stream, err := h.NewStream(ctx, id, LookupProtocol)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	stream.SetDeadline(time.Now().Add(lookupTimeout))
+
+	err = json.NewEncoder(stream).Encode(&request)
+	if err != nil {
+		stream.Reset()
+		return nil, err
+	}
+	err = stream.CloseWrite()
+	if err != nil {
+		stream.Reset()
+		return nil, err
+	}
+
+	var resp lookupResponse
+	err = json.NewDecoder(io.LimitReader(stream, lookupMaxSize)).Decode(&resp)
+	if err != nil {
+		stream.Reset()
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+func (p *P2P) handleLookup(stream network.Stream) {
+	defer stream.Close()
+	stream.SetDeadline(time.Now().Add(lookupTimeout))
+
+	var request lookupRequest
+	err := json.NewDecoder(io.LimitReader(stream, lookupMaxRequestSize)).Decode(&request)
+	if err != nil || len(request.Name) == 0 {
+		stream.Reset()
+		return
+	}
+
+	// entries must not leak between rooms, and lookups are subject to confidential rules
+	logger := log.WithField("PeerID", stream.Conn().RemotePeer()).WithField("Name", request.Name)
+	if room := p.RoomFor(request.Name); len(room) == 0 || room != request.Room {
+		logger.WithField("Room", request.Room).Debug("Lookup refused, key not replicated in room")
+		stream.Reset()
+		return
+	}
+	if err := p.lookupValidator(request.Name, request.Request); err != nil {
+		logger.WithError(err).Debug("Lookup refused")
+		stream.Reset()
+		return
+	}
+
+	items, err := p.sync.Lookup(request.Name)
+	if err != nil {
+		logger.WithError(err).Error("Failed to lookup directory")
+		stream.Reset()
+		return
+	}
+
+	err = json.NewEncoder(stream).Encode(&lookupResponse{Items: items})
+	if err != nil {
+		stream.Reset()
+		return
+	}
+}
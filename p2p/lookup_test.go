@@ -0,0 +1,105 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	soroban "code.samourai.io/wallet/samourai-soroban"
+	"code.samourai.io/wallet/samourai-soroban/confidential"
+	"code.samourai.io/wallet/samourai-soroban/internal/common"
+	"code.samourai.io/wallet/samourai-soroban/internal/memory"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestLookup(t *testing.T) {
+	directory := memory.NewWithDomain("test", memory.DefaultCacheCapacity, memory.DefaultCacheTTL)
+	directory.Add("key", "a", time.Minute)
+	directory.Add("secret", "b", time.Minute)
+
+	remote := P2P{
+		rooms: []Room{{Name: "default"}, {Name: "team", Prefixes: []string{"team.*"}}},
+		sync:  directory,
+		lookupValidator: func(name string, request []byte) error {
+			if name == "secret" && string(request) != `"signed"` {
+				return errors.New("not signed")
+			}
+			return nil
+		},
+	}
+	dialer := newSecurityHost(t, DefaultSecurity)
+	remoteHost := newSecurityHost(t, DefaultSecurity)
+	remoteHost.SetStreamHandler(LookupProtocol, remote.handleLookup)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := dialer.Connect(ctx, peer.AddrInfo{ID: remoteHost.ID(), Addrs: remoteHost.Addrs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		request lookupRequest
+		want    int
+		wantErr bool
+	}{
+		{"found", lookupRequest{Room: "default", Name: "key"}, 1, false},
+		{"missing", lookupRequest{Room: "default", Name: "missing"}, 0, false},
+		{"room", lookupRequest{Room: "team", Name: "key"}, 0, true},
+		{"refused", lookupRequest{Room: "default", Name: "secret"}, 0, true},
+		{"signed", lookupRequest{Room: "default", Name: "secret", Request: []byte(`"signed"`)}, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := lookupPeer(ctx, dialer, remoteHost.ID(), tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("lookupPeer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(items) != tt.want {
+				t.Errorf("lookupPeer() = %v, want %d items", items, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeItems(t *testing.T) {
+	now := time.Now()
+	first := []soroban.DirectoryItem{
+		{Name: "key", Value: "a", ExpireOn: now.Add(time.Minute)},
+		{Name: "key", Value: "b", ExpireOn: now.Add(time.Minute)},
+		{Name: "other", Value: "c", ExpireOn: now.Add(time.Minute)},
+	}
+	second := []soroban.DirectoryItem{
+		{Name: "key", Value: "a", ExpireOn: now.Add(2 * time.Minute)},
+		{Name: "key", Value: "b", ExpireOn: now.Add(time.Hour)},
+		{Name: "key", Value: "d", ExpireOn: now.Add(-time.Minute)},
+	}
+
+	items := mergeItems("key", first, second)
+	if len(items) != 2 || items[0].Value != "a" || items[1].Value != "b" {
+		t.Fatalf("mergeItems() = %v", items)
+	}
+	if !items[0].ExpireOn.Equal(now.Add(2 * time.Minute)) {
+		t.Errorf("mergeItems() expire on = %v, want latest", items[0].ExpireOn)
+	}
+	if items[1].ExpireOn.After(time.Now().Add(common.MaxTimeToLive())) {
+		t.Errorf("mergeItems() expire on = %v, want capped", items[1].ExpireOn)
+	}
+}
+
+func TestLookupProtected(t *testing.T) {
+	defer confidential.SetConfig(confidential.Config())
+	confidential.SetConfig(confidential.SorobanConfig{
+		Confidential: []confidential.ConfidentialEntry{
+			{Prefix: "readonly.*", ReadOnly: true},
+		},
+	})
+
+	p := P2P{host: newSecurityHost(t, DefaultSecurity), rooms: []Room{{Name: "default"}}}
+	_, err := p.Lookup(context.Background(), "readonly.key", nil)
+	if err == nil {
+		t.Error("Lookup() expected error for readonly key")
+	}
+}
@@ -1,13 +1,42 @@
 package p2p
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// MaxHops is the maximum count of forwards between nodes and processes
+	MaxHops = 8
 )
 
+// Message is the envelope for p2p and IPC messages.
+// ID is unique per message and kept when forwarded, Origin is the peer ID of the node
+// which published the message and Hops the count of forwards.
+// Origin is set by the sender, From is the pubsub sender authenticated by its signature, set on receive and never encoded.
 type Message struct {
+	ID      string `json:",omitempty"`
+	Origin  string `json:",omitempty"`
+	Hops    int    `json:",omitempty"`
 	Context string
 	Payload []byte
+	From    peer.ID `json:"-"`
+}
+
+func newMessageID() string {
+	var id [16]byte
+	_, err := rand.Read(id[:])
+	if err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(id[:])
 }
 
 func NewMessage(context string, obj interface{}) (Message, error) {
@@ -23,15 +52,20 @@ func NewMessage(context string, obj interface{}) (Message, error) {
 	}
 
 	return Message{
+		ID:      newMessageID(),
 		Context: context,
 		Payload: payload,
 	}, nil
 }
 
+// MessageFromBytes decode message from binary envelope or JSON
 func MessageFromBytes(data []byte) (Message, error) {
 	if len(data) == 0 {
 		return Message{}, errors.New("invalid data")
 	}
+	if isEnvelope(data) {
+		return messageFromEnvelope(data)
+	}
 
 	var result Message
 	err := json.Unmarshal(data, &result)
@@ -42,6 +76,7 @@ func MessageFromBytes(data []byte) (Message, error) {
 	return result, nil
 }
 
+// ToBytes encode message in JSON, understood by all peers
 func (p *Message) ToBytes() ([]byte, error) {
 	if len(p.Context) == 0 {
 		return nil, errors.New("invalid context")
@@ -66,3 +101,38 @@ func (p *Message) ParsePayload(obj interface{}) error {
 
 	return json.Unmarshal(p.Payload, obj)
 }
+
+// Forward return message copy with incremented hop count.
+func (p Message) Forward() (Message, error) {
+	if p.Hops >= MaxHops {
+		return Message{}, errors.New("too many hops")
+	}
+	p.Hops++
+	return p, nil
+}
+
+// Hash return digest of message ID, context and payload, used to drop duplicates.
+// ID is chosen by sender, a message with the ID of another message must not shadow it.
+// Origin and Hops change when forwarded and are not part of the hash.
+func (p *Message) Hash() string {
+	if len(p.ID) == 0 {
+		return ""
+	}
+	hash := sha256.New()
+	for _, field := range [][]byte{[]byte(p.ID), []byte(p.Context), p.Payload} {
+		// fields are length prefixed, boundaries can't be moved
+		var size [8]byte
+		binary.BigEndian.PutUint64(size[:], uint64(len(field)))
+		hash.Write(size[:])
+		hash.Write(field)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// messageID is the gossipsub message ID function, digest of raw message data.
+// Message is not decoded, compressed envelopes are only inflated by validators after sender rate limit.
+// Duplicates forwarded with another encoding or hop count are dropped by message hash once decoded.
+func messageID(pmsg *pb.Message) string {
+	hash := sha256.Sum256(pmsg.GetData())
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,199 @@
+package p2p
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+)
+
+func TestMessageForward(t *testing.T) {
+	message, err := NewMessage("Directory.Add", map[string]string{"Name": "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(message.ID) == 0 {
+		t.Fatal("NewMessage() without ID")
+	}
+
+	hash := message.Hash()
+	for i := 0; i < MaxHops; i++ {
+		message, err = message.Forward()
+		if err != nil {
+			t.Fatalf("Forward() hop %d error = %v", i, err)
+		}
+	}
+	if _, err := message.Forward(); err == nil {
+		t.Error("Forward() expected error after MaxHops")
+	}
+	// forwarded messages are duplicates
+	if message.Hash() != hash {
+		t.Error("Hash() depends on hops")
+	}
+}
+
+func TestMessageID(t *testing.T) {
+	message, err := NewMessage("Directory.Add", map[string]string{"Name": "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := message.ToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := messageID(&pb.Message{Data: data})
+	if id == message.ID || id != messageID(&pb.Message{Data: append([]byte{}, data...)}) {
+		t.Errorf("messageID() = %v, want raw data hash", id)
+	}
+
+	// envelopes are not decoded, corrupted compressed bodies still get an ID
+	corrupted := []byte{envelopeMagic, EnvelopeVersion, envelopeCompressed, 0xff, 0xff}
+	if id := messageID(&pb.Message{Data: corrupted}); len(id) == 0 || id == messageID(&pb.Message{Data: data}) {
+		t.Errorf("messageID() = %v", id)
+	}
+
+	// a message reusing the ID of another message is not a duplicate
+	other, err := NewMessage("Directory.Add", map[string]string{"Name": "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.ID = message.ID
+	if other.Hash() == message.Hash() {
+		t.Error("Hash() same hash for different payloads")
+	}
+	// origin is set by publisher, not part of hash
+	other = message
+	other.Origin = "origin"
+	if other.Hash() != message.Hash() {
+		t.Error("Hash() depends on origin")
+	}
+}
+
+func TestSeenCache(t *testing.T) {
+	seen := NewSeenCache(time.Minute)
+	if seen.Seen("") || seen.Seen("") {
+		t.Error("Seen() empty ID must never be seen")
+	}
+	if seen.Seen("id") {
+		t.Error("Seen() new ID")
+	}
+	if !seen.Seen("id") {
+		t.Error("Seen() duplicate ID")
+	}
+}
+
+func TestMessageEnvelope(t *testing.T) {
+	message, err := NewMessage("Directory.Add", map[string]string{"Name": "test", "Entry": strings.Repeat("entry", 100)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	message.Origin = "origin"
+	message.Hops = 2
+
+	data, err := message.ToEnvelope()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[2]&envelopeCompressed == 0 {
+		t.Error("ToEnvelope() expected compressed body")
+	}
+	jsonData, err := message.ToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) >= len(jsonData) {
+		t.Errorf("ToEnvelope() size %d, not smaller than json %d", len(data), len(jsonData))
+	}
+
+	got, err := MessageFromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, message) {
+		t.Errorf("MessageFromBytes() = %v, want %v", got, message)
+	}
+	// json messages of older peers
+	got, err = MessageFromBytes(jsonData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, message) {
+		t.Errorf("MessageFromBytes() json = %v, want %v", got, message)
+	}
+}
+
+func TestMessageEnvelopeInvalid(t *testing.T) {
+	message, err := NewMessage("Directory.Add", map[string]string{"Name": "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := message.ToEnvelope()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[2]&envelopeCompressed != 0 {
+		t.Error("ToEnvelope() small message must not be compressed")
+	}
+
+	future := append([]byte{}, data...)
+	future[1] = EnvelopeVersion + 1
+	if _, err := MessageFromBytes(future); err == nil {
+		t.Error("MessageFromBytes() expected error for unknown version")
+	}
+	if _, err := MessageFromBytes(data[:len(data)-1]); err == nil {
+		t.Error("MessageFromBytes() expected error for truncated envelope")
+	}
+
+	message.Hops = MaxHops + 1
+	data, err = message.ToEnvelope()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MessageFromBytes(data); err == nil {
+		t.Error("MessageFromBytes() expected error for too many hops")
+	}
+}
+
+func TestParseEncoding(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"", EncodingAuto, false},
+		{"JSON", EncodingJSON, false},
+		{"binary", EncodingBinary, false},
+		{"cbor", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseEncoding(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseEncoding(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestMessageFromNotEncoded(t *testing.T) {
+	message, err := NewMessage("Directory.Add", map[string]string{"Name": "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	message.From = newTestPeerID(t)
+
+	for _, encode := range []func() ([]byte, error){message.ToBytes, message.ToEnvelope} {
+		data, err := encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := MessageFromBytes(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.From) != 0 {
+			t.Errorf("MessageFromBytes() From = %v, sender must not be encoded", got.From)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/sec"
 	"golang.org/x/net/proxy"
 
 	tpt "github.com/libp2p/go-libp2p/core/transport"
@@ -25,6 +26,7 @@ type OnionTransport struct {
 	dialer        proxy.Dialer
 	dialOnlyOnion bool
 	laddr         ma.Multiaddr
+	secMuxer      sec.SecureMuxer
 
 	// Connection upgrader for upgrading insecure stream connections to
 	// secure multiplex connections.
@@ -33,13 +35,15 @@ type OnionTransport struct {
 
 var _ tpt.Transport = &OnionTransport{}
 
-// NewOnionTransport creates a new OnionTransport
-func NewOnionTransport(sk crypto.PrivKey, dialer proxy.Dialer, service *tor.OnionService, dialOnlyOnion bool, upgrader tpt.Upgrader) (*OnionTransport, error) {
+// NewOnionTransport creates a new OnionTransport.
+// Outbound connections are secured with secMuxer transports, negotiated by multistream.
+func NewOnionTransport(sk crypto.PrivKey, dialer proxy.Dialer, service *tor.OnionService, dialOnlyOnion bool, secMuxer sec.SecureMuxer, upgrader tpt.Upgrader) (*OnionTransport, error) {
 	o := OnionTransport{
 		sk:            sk,
 		dialer:        dialer,
 		service:       service,
 		dialOnlyOnion: dialOnlyOnion,
+		secMuxer:      secMuxer,
 		Upgrader:      upgrader,
 	}
 	return &o, nil
@@ -51,9 +55,9 @@ type OnionTransportC func(upgrader tpt.Upgrader) (tpt.Transport, error)
 
 // NewOnionTransportC is a convenience function that returns a function
 // suitable for passing into libp2p.Transport for host configuration
-func NewOnionTransportC(sk crypto.PrivKey, dialer proxy.Dialer, service *tor.OnionService, dialOnlyOnion bool) OnionTransportC {
+func NewOnionTransportC(sk crypto.PrivKey, dialer proxy.Dialer, service *tor.OnionService, dialOnlyOnion bool, secMuxer sec.SecureMuxer) OnionTransportC {
 	return func(upgrader tpt.Upgrader) (tpt.Transport, error) {
-		return NewOnionTransport(sk, dialer, service, dialOnlyOnion, upgrader)
+		return NewOnionTransport(sk, dialer, service, dialOnlyOnion, secMuxer, upgrader)
 	}
 }
 
@@ -86,7 +90,7 @@ func (t *OnionTransport) Dial(ctx context.Context, raddr ma.Multiaddr, p peer.ID
 		return nil, err
 	}
 
-	u, err := makeUpgrader(t.sk, p)
+	u, err := makeUpgrader(t.secMuxer)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,214 @@
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// peerBookMaxPeers is the maximum count of peers saved, most recently seen first
+	peerBookMaxPeers = 256
+	// peerBookMaxAge of peers not seen
+	peerBookMaxAge = 7 * 24 * time.Hour
+	// peerBookSaveDelay between peer book saves
+	peerBookSaveDelay = time.Minute
+)
+
+type peerBookEntry struct {
+	ID       string
+	Addrs    []string
+	LastSeen time.Time
+}
+
+// PeerBook keep known peers addresses on disk, to reconnect on restart without bootstrap
+type PeerBook struct {
+	filename string
+
+	mutex sync.Mutex
+	peers map[peer.ID]peerBookEntry
+	dirty bool
+}
+
+// LoadPeerBook read peer book from file, missing file is an empty peer book
+func LoadPeerBook(filename string) (*PeerBook, error) {
+	result := PeerBook{
+		filename: filename,
+		peers:    make(map[peer.ID]peerBookEntry),
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return &result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []peerBookEntry
+	err = json.Unmarshal(data, &entries)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		id, err := peer.Decode(entry.ID)
+		if err != nil {
+			continue
+		}
+		result.peers[id] = entry
+	}
+	result.evictLocked()
+	return &result, nil
+}
+
+// Add peer addresses, seen now
+func (p *PeerBook) Add(info peer.AddrInfo) {
+	if len(info.Addrs) == 0 {
+		return
+	}
+	entry := peerBookEntry{
+		ID:       info.ID.String(),
+		LastSeen: time.Now().UTC(),
+	}
+	for _, addr := range info.Addrs {
+		entry.Addrs = append(entry.Addrs, addr.String())
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.peers[info.ID] = entry
+	p.evictLocked()
+	p.dirty = true
+}
+
+// Len return count of known peers, without too old peers
+func (p *PeerBook) Len() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return len(p.entries())
+}
+
+// evictLocked remove least recently seen peers above peerBookMaxPeers, must be called with mutex locked
+func (p *PeerBook) evictLocked() {
+	for len(p.peers) > peerBookMaxPeers {
+		var oldest peer.ID
+		var lastSeen time.Time
+		for id, entry := range p.peers {
+			if len(oldest) == 0 || entry.LastSeen.Before(lastSeen) {
+				oldest, lastSeen = id, entry.LastSeen
+			}
+		}
+		delete(p.peers, oldest)
+	}
+}
+
+// AddrInfos return known peers, skipping too old peers
+func (p *PeerBook) AddrInfos() []peer.AddrInfo {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var result []peer.AddrInfo
+	for _, entry := range p.entries() {
+		id, err := peer.Decode(entry.ID)
+		if err != nil {
+			continue
+		}
+		info := peer.AddrInfo{ID: id}
+		for _, addr := range entry.Addrs {
+			maddr, err := multiaddr.NewMultiaddr(addr)
+			if err != nil {
+				continue
+			}
+			info.Addrs = append(info.Addrs, maddr)
+		}
+		if len(info.Addrs) == 0 {
+			continue
+		}
+		result = append(result, info)
+	}
+	return result
+}
+
+// entries return most recently seen entries, without too old entries
+func (p *PeerBook) entries() []peerBookEntry {
+	limit := time.Now().Add(-peerBookMaxAge)
+
+	var result []peerBookEntry
+	for _, entry := range p.peers {
+		if entry.LastSeen.Before(limit) {
+			continue
+		}
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastSeen.After(result[j].LastSeen)
+	})
+	if len(result) > peerBookMaxPeers {
+		result = result[:peerBookMaxPeers]
+	}
+	return result
+}
+
+// Save peer book to file atomically, if changed
+func (p *PeerBook) Save() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(p.entries(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(p.filename), ".peers-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	err = os.Rename(tmp.Name(), p.filename)
+	if err != nil {
+		return err
+	}
+	p.dirty = false
+	return nil
+}
+
+// Start save peer book periodically, and on exit
+func (p *PeerBook) Start(ctx context.Context) {
+	for {
+		select {
+		case <-time.After(peerBookSaveDelay):
+		case <-ctx.Done():
+		}
+
+		if err := p.Save(); err != nil {
+			log.WithError(err).WithField("Filename", p.filename).Error("Failed to save peer book")
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
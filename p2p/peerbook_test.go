@@ -0,0 +1,65 @@
+package p2p
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+func TestPeerBook(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "peers.json")
+
+	book, err := LoadPeerBook(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book.AddrInfos()) != 0 {
+		t.Fatal("LoadPeerBook() expected empty peer book")
+	}
+
+	id := newTestPeerID(t)
+	book.Add(peer.AddrInfo{
+		ID:    id,
+		Addrs: []multiaddr.Multiaddr{multiaddr.StringCast("/ip4/127.0.0.1/tcp/1042")},
+	})
+	if err := book.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	book, err = LoadPeerBook(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos := book.AddrInfos()
+	if len(infos) != 1 || infos[0].ID != id || len(infos[0].Addrs) != 1 {
+		t.Errorf("AddrInfos() = %v", infos)
+	}
+}
+
+func TestPeerBookEviction(t *testing.T) {
+	book, err := LoadPeerBook(filepath.Join(t.TempDir(), "peers.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addrs := []multiaddr.Multiaddr{multiaddr.StringCast("/ip4/127.0.0.1/tcp/1042")}
+	oldest := newTestPeerID(t)
+	book.Add(peer.AddrInfo{ID: oldest, Addrs: addrs})
+	book.peers[oldest] = peerBookEntry{ID: oldest.String(), Addrs: []string{addrs[0].String()}, LastSeen: time.Now().Add(-time.Hour)}
+
+	for i := 0; i < peerBookMaxPeers; i++ {
+		book.Add(peer.AddrInfo{ID: newTestPeerID(t), Addrs: addrs})
+	}
+	if len(book.peers) != peerBookMaxPeers {
+		t.Errorf("Add() count = %d, want %d", len(book.peers), peerBookMaxPeers)
+	}
+	if _, ok := book.peers[oldest]; ok {
+		t.Error("Add() least recently seen peer not evicted")
+	}
+	if book.Len() != peerBookMaxPeers {
+		t.Errorf("Len() = %d, want %d", book.Len(), peerBookMaxPeers)
+	}
+}
@@ -0,0 +1,243 @@
+package p2p
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+const (
+	// heartbeatRetention of heartbeat times received from peers
+	heartbeatRetention = time.Hour
+)
+
+// PeerInfo is a peer known by the p2p node
+type PeerInfo struct {
+	PeerID        string
+	Addrs         []string
+	Connectedness string
+	Latency       string     `json:",omitempty"`
+	RTT           string     `json:",omitempty"`
+	ClockOffset   string     `json:",omitempty"`
+	Version       string     `json:",omitempty"`
+	Envelope      int        `json:",omitempty"`
+	Mesh          []string   `json:",omitempty"`
+	LastHeartbeat *time.Time `json:",omitempty"`
+}
+
+// Peers is the topology of p2p node, reported by children to IPC server
+type Peers struct {
+	PeerID        string
+	ListenAddrs   []string
+	RoutingTable  int
+	Mesh          map[string]int
+	Peers         []PeerInfo
+	LastHeartbeat *time.Time `json:",omitempty"`
+	HeartbeatSent *time.Time `json:",omitempty"`
+	UpdatedAt     time.Time
+}
+
+// HeartbeatReceived record heartbeat time of origin peer
+func (p *P2P) HeartbeatReceived(origin string) {
+	id, err := peer.Decode(origin)
+	if err != nil {
+		return
+	}
+	now := time.Now().UTC()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.heartbeats == nil {
+		p.heartbeats = make(map[peer.ID]time.Time)
+	}
+	for key, date := range p.heartbeats {
+		if now.Sub(date) > heartbeatRetention {
+			delete(p.heartbeats, key)
+		}
+	}
+	p.heartbeats[id] = now
+	p.lastHeartbeat = now
+	p.setStateLocked(StateConnected)
+}
+
+// LastHeartbeat return last heartbeat time received from any peer, zero if none
+func (p *P2P) LastHeartbeat() time.Time {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.lastHeartbeat
+}
+
+// HeartbeatSent record heartbeat time of local peer
+func (p *P2P) HeartbeatSent() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.heartbeatSent = time.Now().UTC()
+}
+
+// Peers return peers connected to host, with latency, mesh membership and last heartbeat
+func (p *P2P) Peers() Peers {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	result := Peers{
+		Mesh:      make(map[string]int),
+		UpdatedAt: time.Now().UTC(),
+	}
+	if !p.lastHeartbeat.IsZero() {
+		lastHeartbeat := p.lastHeartbeat
+		result.LastHeartbeat = &lastHeartbeat
+	}
+	if !p.heartbeatSent.IsZero() {
+		heartbeatSent := p.heartbeatSent
+		result.HeartbeatSent = &heartbeatSent
+	}
+	if p.host == nil {
+		return result
+	}
+
+	result.PeerID = p.host.ID().String()
+	for _, addr := range p.host.Addrs() {
+		result.ListenAddrs = append(result.ListenAddrs, addr.String())
+	}
+	if p.kdht != nil {
+		result.RoutingTable = p.kdht.RoutingTable().Size()
+	}
+
+	var mesh map[peer.ID][]string
+	if p.mesh != nil {
+		mesh = p.mesh.peers()
+		for _, room := range p.rooms {
+			result.Mesh[room.Name] = 0
+		}
+		for _, rooms := range mesh {
+			for _, room := range rooms {
+				result.Mesh[room]++
+			}
+		}
+	}
+
+	// connected peers and mesh peers
+	ids := make(map[peer.ID]bool)
+	for _, id := range p.host.Network().Peers() {
+		ids[id] = true
+	}
+	for id := range mesh {
+		ids[id] = true
+	}
+
+	network, peerstore := p.host.Network(), p.host.Peerstore()
+	for id := range ids {
+		info := PeerInfo{
+			PeerID:        id.String(),
+			Connectedness: network.Connectedness(id).String(),
+			Mesh:          mesh[id],
+		}
+		for _, addr := range peerstore.Addrs(id) {
+			info.Addrs = append(info.Addrs, addr.String())
+		}
+		if latency := peerstore.LatencyEWMA(id); latency > 0 {
+			info.Latency = latency.String()
+		}
+		if date, ok := p.heartbeats[id]; ok {
+			info.LastHeartbeat = &date
+		}
+		if health, ok := p.health[id]; ok {
+			info.RTT = health.RTT.String()
+			info.ClockOffset = health.ClockOffset.String()
+			info.Version = health.Version
+			info.Envelope = health.Envelope
+		}
+		result.Peers = append(result.Peers, info)
+	}
+	sort.Slice(result.Peers, func(i, j int) bool {
+		return result.Peers[i].PeerID < result.Peers[j].PeerID
+	})
+	return result
+}
+
+// meshTracer keep gossipsub mesh membership of peers, from graft and prune events
+type meshTracer struct {
+	mutex sync.Mutex
+	mesh  map[peer.ID]map[string]bool
+}
+
+func newMeshTracer() *meshTracer {
+	return &meshTracer{
+		mesh: make(map[peer.ID]map[string]bool),
+	}
+}
+
+// peers return mesh rooms of peers
+func (p *meshTracer) peers() map[peer.ID][]string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	result := make(map[peer.ID][]string)
+	for id, topics := range p.mesh {
+		for topic := range topics {
+			result[id] = append(result[id], topic)
+		}
+		sort.Strings(result[id])
+	}
+	return result
+}
+
+func (p *meshTracer) Graft(id peer.ID, topic string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if _, ok := p.mesh[id]; !ok {
+		p.mesh[id] = make(map[string]bool)
+	}
+	p.mesh[id][topic] = true
+}
+
+func (p *meshTracer) Prune(id peer.ID, topic string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	delete(p.mesh[id], topic)
+	if len(p.mesh[id]) == 0 {
+		delete(p.mesh, id)
+	}
+}
+
+func (p *meshTracer) RemovePeer(id peer.ID) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	delete(p.mesh, id)
+}
+
+func (p *meshTracer) Leave(topic string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for id, topics := range p.mesh {
+		delete(topics, topic)
+		if len(topics) == 0 {
+			delete(p.mesh, id)
+		}
+	}
+}
+
+// other gossipsub events are ignored
+
+func (p *meshTracer) AddPeer(peer.ID, protocol.ID)          {}
+func (p *meshTracer) Join(string)                           {}
+func (p *meshTracer) ValidateMessage(*pubsub.Message)       {}
+func (p *meshTracer) DeliverMessage(*pubsub.Message)        {}
+func (p *meshTracer) RejectMessage(*pubsub.Message, string) {}
+func (p *meshTracer) DuplicateMessage(*pubsub.Message)      {}
+func (p *meshTracer) ThrottlePeer(peer.ID)                  {}
+func (p *meshTracer) RecvRPC(*pubsub.RPC)                   {}
+func (p *meshTracer) SendRPC(*pubsub.RPC, peer.ID)          {}
+func (p *meshTracer) DropRPC(*pubsub.RPC, peer.ID)          {}
+func (p *meshTracer) UndeliverableMessage(*pubsub.Message)  {}
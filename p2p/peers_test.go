@@ -0,0 +1,49 @@
+package p2p
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMeshTracer(t *testing.T) {
+	first := newTestPeerID(t)
+	second := newTestPeerID(t)
+
+	tracer := newMeshTracer()
+	tracer.Graft(first, "soroban")
+	tracer.Graft(first, "wallets")
+	tracer.Graft(second, "soroban")
+	tracer.Prune(second, "soroban")
+
+	peers := tracer.peers()
+	if !reflect.DeepEqual(peers[first], []string{"soroban", "wallets"}) {
+		t.Errorf("peers() = %v", peers[first])
+	}
+	if _, ok := peers[second]; ok {
+		t.Error("peers() expected pruned peer removed")
+	}
+
+	tracer.Leave("wallets")
+	tracer.RemovePeer(second)
+	if peers := tracer.peers(); !reflect.DeepEqual(peers[first], []string{"soroban"}) {
+		t.Errorf("peers() after Leave = %v", peers[first])
+	}
+}
+
+func TestPeersHeartbeat(t *testing.T) {
+	var p2P P2P
+	if peers := p2P.Peers(); peers.LastHeartbeat != nil || peers.HeartbeatSent != nil {
+		t.Error("Peers() expected no heartbeat")
+	}
+
+	p2P.HeartbeatReceived("invalid")
+	if peers := p2P.Peers(); peers.LastHeartbeat != nil {
+		t.Error("HeartbeatReceived() expected invalid origin ignored")
+	}
+
+	p2P.HeartbeatReceived(newTestPeerID(t).String())
+	p2P.HeartbeatSent()
+	if peers := p2P.Peers(); peers.LastHeartbeat == nil || peers.HeartbeatSent == nil {
+		t.Error("Peers() expected heartbeat times")
+	}
+}
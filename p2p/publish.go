@@ -0,0 +1,133 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// pendingMaxCount of messages published before rooms are joined
+	pendingMaxCount = 1024
+	// publishReadyTimeout waiting for mesh peers, shorter than IPC request timeout
+	publishReadyTimeout = 4 * time.Second
+)
+
+var (
+	// ErrNotStarted is returned when publishing without p2p, children publish in IPC mode
+	ErrNotStarted = errors.New("p2p not started")
+	// ErrPendingFull is returned when too many messages are waiting for rooms to be joined
+	ErrPendingFull = errors.New("too many pending messages")
+)
+
+type pendingMessage struct {
+	room string
+	data []byte
+}
+
+// publish message to room topic, default room if empty.
+// Messages are kept pending until room is joined, and wait for room MinPeers mesh peers if set.
+func (p *P2P) publish(ctx context.Context, room, msg string) error {
+	if len(msg) == 0 {
+		return errors.New("failed to publish empty message")
+	}
+
+	p.mutex.Lock()
+	if len(p.state) == 0 {
+		p.mutex.Unlock()
+		return ErrNotStarted
+	}
+	if len(room) == 0 && len(p.rooms) > 0 {
+		room = p.rooms[0].Name
+	}
+	info, ok := p.room(room)
+	if !ok {
+		p.mutex.Unlock()
+		return fmt.Errorf("unknown room %s", room)
+	}
+	topic := p.topics[room]
+	if topic == nil {
+		defer p.mutex.Unlock()
+		return p.addPendingLocked(room, []byte(msg))
+	}
+	p.mutex.Unlock()
+
+	var opts []pubsub.PubOpt
+	if info.MinPeers > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, publishReadyTimeout)
+		defer cancel()
+		opts = append(opts, pubsub.WithReadiness(pubsub.MinTopicSize(info.MinPeers)))
+	}
+
+	err := topic.Publish(ctx, []byte(msg), opts...)
+	switch {
+	case err == pubsub.ErrTopicClosed:
+		// room is rejoined during recovery, publish to new topic if already joined
+		p.mutex.Lock()
+		if current := p.topics[room]; current != nil && current != topic {
+			p.mutex.Unlock()
+			return p.publish(ctx, room, msg)
+		}
+		defer p.mutex.Unlock()
+		return p.addPendingLocked(room, []byte(msg))
+
+	case err != nil && info.MinPeers > 0 && ctx.Err() != nil:
+		return fmt.Errorf("less than %d mesh peers in room %s: %w", info.MinPeers, room, err)
+	}
+	return err
+}
+
+// room return room by name, must be called with mutex locked
+func (p *P2P) room(name string) (Room, bool) {
+	for _, room := range p.rooms {
+		if room.Name == name {
+			return room, true
+		}
+	}
+	return Room{}, false
+}
+
+// addPendingLocked keep message until room is joined, must be called with mutex locked
+func (p *P2P) addPendingLocked(room string, data []byte) error {
+	if len(p.pending) >= pendingMaxCount {
+		return ErrPendingFull
+	}
+	p.pending = append(p.pending, pendingMessage{
+		room: room,
+		data: data,
+	})
+	log.WithField("Room", room).WithField("Count", len(p.pending)).Debug("P2P message pending")
+	return nil
+}
+
+// PendingCount return count of messages waiting for rooms to be joined
+func (p *P2P) PendingCount() int {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return len(p.pending)
+}
+
+// flushPending publish pending messages of joined rooms
+func (p *P2P) flushPending(ctx context.Context) {
+	p.mutex.Lock()
+	pending := p.pending
+	p.pending = nil
+	p.mutex.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+	log.WithField("Count", len(pending)).Info("P2P publish pending messages")
+	for _, message := range pending {
+		if err := p.publish(ctx, message.room, string(message.data)); err != nil {
+			log.WithError(err).WithField("Room", message.room).Warning("Failed to publish pending message")
+		}
+	}
+}
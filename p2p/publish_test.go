@@ -0,0 +1,65 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+func TestPublish(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var p2P P2P
+	if err := p2P.Publish(ctx, "message"); err != ErrNotStarted {
+		t.Fatalf("Publish() error = %v, want %v", err, ErrNotStarted)
+	}
+
+	// pending until rooms are joined
+	p2P.rooms = []Room{{Name: "default"}, {Name: "critical", Prefixes: []string{"critical.*"}, MinPeers: 1}}
+	p2P.setState(StateStarting)
+	if err := p2P.Publish(ctx, "message"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p2P.publish(ctx, "unknown", "message"); err == nil {
+		t.Error("publish() expected error for unknown room")
+	}
+	if count := p2P.PendingCount(); count != 1 {
+		t.Errorf("PendingCount() = %d, want 1", count)
+	}
+
+	gossipSub, err := pubsub.NewGossipSub(ctx, newSecurityHost(t, DefaultSecurity))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2P.topics = make(map[string]*pubsub.Topic)
+	for _, room := range p2P.rooms {
+		topic, err := gossipSub.Join(room.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p2P.topics[room.Name] = topic
+	}
+	p2P.flushPending(ctx)
+	if count := p2P.PendingCount(); count != 0 {
+		t.Errorf("PendingCount() = %d after flush, want 0", count)
+	}
+
+	// critical writes fail without mesh peers
+	timeout, cancelTimeout := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancelTimeout()
+	if err := p2P.publish(timeout, "critical", "message"); err == nil {
+		t.Error("publish() expected error without mesh peers")
+	}
+
+	// closed topic is pending until room is rejoined
+	p2P.topics["default"].Close()
+	if err := p2P.Publish(ctx, "message"); err != nil {
+		t.Fatal(err)
+	}
+	if count := p2P.PendingCount(); count != 1 {
+		t.Errorf("PendingCount() = %d after close, want 1", count)
+	}
+}
@@ -0,0 +1,121 @@
+package p2p
+
+import (
+	"fmt"
+	"sync"
+
+	soroban "code.samourai.io/wallet/samourai-soroban"
+)
+
+// Drop policies of full message queue
+const (
+	DropOldest = "oldest"
+	DropNewest = "newest"
+)
+
+// QueueStats are counters of inbound message queue
+type QueueStats struct {
+	Size      int
+	Workers   int
+	Pending   int
+	Queued    uint64
+	Dropped   uint64
+	Processed uint64
+}
+
+// MessageQueue is a bounded queue of inbound messages, consumed by a pool of workers.
+// When full, messages are dropped according to drop policy instead of blocking gossip consumption.
+type MessageQueue struct {
+	messages   chan Message
+	workers    int
+	dropPolicy string
+
+	mutex     sync.Mutex
+	queued    uint64
+	dropped   uint64
+	processed uint64
+}
+
+// NewMessageQueue create message queue from options
+func NewMessageQueue(info soroban.QueueInfo) (*MessageQueue, error) {
+	if info.Size <= 0 {
+		return nil, fmt.Errorf("invalid queue size %d", info.Size)
+	}
+	if info.Workers <= 0 {
+		return nil, fmt.Errorf("invalid queue workers %d", info.Workers)
+	}
+	switch info.DropPolicy {
+	case DropOldest, DropNewest:
+	default:
+		return nil, fmt.Errorf("unknown drop policy %s", info.DropPolicy)
+	}
+
+	return &MessageQueue{
+		messages:   make(chan Message, info.Size),
+		workers:    info.Workers,
+		dropPolicy: info.DropPolicy,
+	}, nil
+}
+
+// Messages return queued messages channel, for workers
+func (p *MessageQueue) Messages() <-chan Message {
+	return p.messages
+}
+
+// Workers return count of workers consuming queue
+func (p *MessageQueue) Workers() int {
+	return p.workers
+}
+
+// Push queue message without blocking, return false if a message was dropped
+func (p *MessageQueue) Push(message Message) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for {
+		select {
+		case p.messages <- message:
+			p.queued++
+			return true
+		default:
+		}
+
+		if p.dropPolicy == DropNewest {
+			p.dropped++
+			return false
+		}
+
+		// drop oldest message and retry, workers may have emptied queue meanwhile
+		select {
+		case <-p.messages:
+			p.dropped++
+			p.queued++
+			p.messages <- message
+			return false
+		default:
+		}
+	}
+}
+
+// Done record message processed by a worker
+func (p *MessageQueue) Done() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.processed++
+}
+
+// Stats return queue counters
+func (p *MessageQueue) Stats() QueueStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return QueueStats{
+		Size:      cap(p.messages),
+		Workers:   p.workers,
+		Pending:   len(p.messages),
+		Queued:    p.queued,
+		Dropped:   p.dropped,
+		Processed: p.processed,
+	}
+}
@@ -0,0 +1,71 @@
+package p2p
+
+import (
+	"testing"
+
+	soroban "code.samourai.io/wallet/samourai-soroban"
+)
+
+func TestNewMessageQueue(t *testing.T) {
+	tests := []struct {
+		name    string
+		info    soroban.QueueInfo
+		wantErr bool
+	}{
+		{"valid", soroban.QueueInfo{Size: 8, Workers: 2, DropPolicy: DropOldest}, false},
+		{"size", soroban.QueueInfo{Size: 0, Workers: 2, DropPolicy: DropOldest}, true},
+		{"workers", soroban.QueueInfo{Size: 8, Workers: 0, DropPolicy: DropNewest}, true},
+		{"policy", soroban.QueueInfo{Size: 8, Workers: 2, DropPolicy: "random"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMessageQueue(tt.info)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewMessageQueue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMessageQueueDropPolicy(t *testing.T) {
+	tests := []struct {
+		policy string
+		want   []string
+	}{
+		{DropOldest, []string{"2", "3"}},
+		{DropNewest, []string{"1", "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			queue, err := NewMessageQueue(soroban.QueueInfo{Size: 2, Workers: 1, DropPolicy: tt.policy})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, id := range []string{"1", "2", "3"} {
+				pushed := queue.Push(Message{ID: id})
+				if wantPushed := i < 2; pushed != wantPushed {
+					t.Errorf("Push(%s) = %v, want %v", id, pushed, wantPushed)
+				}
+			}
+
+			for _, id := range tt.want {
+				message := <-queue.Messages()
+				if message.ID != id {
+					t.Errorf("Messages() = %s, want %s", message.ID, id)
+				}
+				queue.Done()
+			}
+
+			stats := queue.Stats()
+			want := QueueStats{Size: 2, Workers: 1, Pending: 0, Dropped: 1, Processed: 2}
+			if tt.policy == DropOldest {
+				want.Queued = 3
+			} else {
+				want.Queued = 2
+			}
+			if stats != want {
+				t.Errorf("Stats() = %+v, want %+v", stats, want)
+			}
+		})
+	}
+}
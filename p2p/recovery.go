@@ -0,0 +1,160 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/discovery/routing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// State of p2p node
+type State string
+
+const (
+	StateStarting   State = "starting"
+	StateConnected  State = "connected"
+	StateDegraded   State = "degraded"
+	StateRecovering State = "recovering"
+)
+
+// State return current state of p2p node, and since when
+func (p *P2P) State() (State, time.Time) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.state, p.stateSince
+}
+
+func (p *P2P) setState(state State) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.setStateLocked(state)
+}
+
+// setStateLocked must be called with mutex locked
+func (p *P2P) setStateLocked(state State) {
+	if p.state == state {
+		return
+	}
+	log.WithField("State", state).WithField("Previous", p.state).Info("P2P state changed")
+	p.state = state
+	p.stateSince = time.Now().UTC()
+}
+
+// SetDegraded mark p2p node degraded when no heartbeat is received, unless recovering
+func (p *P2P) SetDegraded() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.state == StateRecovering {
+		return
+	}
+	p.setStateLocked(StateDegraded)
+}
+
+// Recover re-dial bootstrap and known peers, re-advertise rooms on the DHT and rejoin room topics.
+// Node stays degraded until a heartbeat is received.
+func (p *P2P) Recover(ctx context.Context) error {
+	p.mutex.Lock()
+	host, kdht := p.host, p.kdht
+	peers := append([]peer.AddrInfo{}, p.bootstrap...)
+	if host == nil || kdht == nil {
+		p.mutex.Unlock()
+		return errors.New("p2p not started")
+	}
+	p.setStateLocked(StateRecovering)
+	p.recoveries++
+	p.mutex.Unlock()
+	defer func() {
+		p.mutex.Lock()
+		if p.state == StateRecovering {
+			p.setStateLocked(StateDegraded)
+		}
+		p.mutex.Unlock()
+	}()
+
+	if p.peerBook != nil {
+		peers = append(peers, p.peerBook.AddrInfos()...)
+	}
+	connected := connectPeers(ctx, host, peers)
+	log.WithField("Count", connected).Info("P2P recovery reconnected peers")
+
+	// refresh routing table and re-advertise rooms
+	if err := kdht.Bootstrap(ctx); err != nil {
+		log.WithError(err).Warning("P2P recovery failed to bootstrap DHT")
+	}
+	routingDiscovery := routing.NewRoutingDiscovery(kdht)
+	for _, room := range p.Rooms() {
+		if err := advertize(ctx, routingDiscovery, room, 1); err != nil {
+			log.WithError(err).WithField("Room", room).Warning("P2P recovery failed to advertise")
+		}
+	}
+
+	// rejoin rooms
+	for _, room := range p.Rooms() {
+		if err := p.rejoinRoom(ctx, room); err != nil {
+			log.WithError(err).WithField("Room", room).Error("P2P recovery failed to rejoin room")
+			return err
+		}
+	}
+
+	if connected == 0 && len(host.Network().Peers()) == 0 {
+		return errors.New("no peer reachable")
+	}
+	return nil
+}
+
+// subscribeRoom subscribe to room topic and deliver messages
+func (p *P2P) subscribeRoom(ctx context.Context, room string, topic *pubsub.Topic) error {
+	subscription, err := topic.Subscribe()
+	if err != nil {
+		return err
+	}
+
+	p.mutex.Lock()
+	p.subscriptions[room] = subscription
+	hostID := p.host.ID()
+	p.mutex.Unlock()
+
+	go p.subscribe(ctx, subscription, hostID)
+	return nil
+}
+
+// rejoinRoom leave room topic and join it again, to graft a new mesh
+func (p *P2P) rejoinRoom(ctx context.Context, room string) error {
+	p.mutex.Lock()
+	gossipSub := p.gossipSub
+	topic, subscription := p.topics[room], p.subscriptions[room]
+	delete(p.subscriptions, room)
+	p.mutex.Unlock()
+
+	if subscription != nil {
+		subscription.Cancel()
+	}
+	if topic != nil {
+		if err := topic.Close(); err != nil {
+			return err
+		}
+	}
+
+	topic, err := gossipSub.Join(room)
+	if err != nil {
+		return err
+	}
+	p.mutex.Lock()
+	p.topics[room] = topic
+	p.mutex.Unlock()
+
+	log.WithField("Room", room).Info("P2P room rejoined")
+	if err := p.subscribeRoom(ctx, room, topic); err != nil {
+		return err
+	}
+	p.flushPending(ctx)
+	return nil
+}
@@ -0,0 +1,30 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+)
+
+func TestState(t *testing.T) {
+	var p2P P2P
+	if err := p2P.Recover(context.Background()); err == nil {
+		t.Error("Recover() expected error when not started")
+	}
+
+	p2P.SetDegraded()
+	if state, since := p2P.State(); state != StateDegraded || since.IsZero() {
+		t.Errorf("State() = %s, %v", state, since)
+	}
+
+	// recovering is kept until recovery ends
+	p2P.setState(StateRecovering)
+	p2P.SetDegraded()
+	if state, _ := p2P.State(); state != StateRecovering {
+		t.Errorf("State() = %s, want %s", state, StateRecovering)
+	}
+
+	p2P.HeartbeatReceived(newTestPeerID(t).String())
+	if state, _ := p2P.State(); state != StateConnected {
+		t.Errorf("State() = %s, want %s", state, StateConnected)
+	}
+}
@@ -0,0 +1,78 @@
+package p2p
+
+import (
+	"errors"
+	"fmt"
+
+	"code.samourai.io/wallet/samourai-soroban/confidential"
+)
+
+// Replication policies, other policies are room names
+const (
+	// ReplicationLocal keys never leave the node
+	ReplicationLocal = "local"
+	// ReplicationRoom keys are replicated in the room matching their prefix, default policy
+	ReplicationRoom = "room"
+)
+
+// Replication is the replication policy of keys matching prefix (same syntax as confidential prefixes).
+// Policy is `local`, `room`, or the name of the room replicating keys.
+type Replication struct {
+	Prefix string
+	Policy string
+}
+
+// ValidateReplication check policies are valid and named rooms exist
+func ValidateReplication(rooms []Room, replication []Replication) error {
+	for _, entry := range replication {
+		if len(entry.Prefix) == 0 {
+			return errors.New("replication without prefix")
+		}
+		switch entry.Policy {
+		case ReplicationLocal, ReplicationRoom:
+			continue
+		case "":
+			return fmt.Errorf("replication %s without policy", entry.Prefix)
+		}
+		found := false
+		for _, room := range rooms {
+			found = found || room.Name == entry.Policy
+		}
+		if !found {
+			return fmt.Errorf("replication %s in unknown room %s", entry.Prefix, entry.Policy)
+		}
+	}
+	return nil
+}
+
+// SetReplication set replication policies, first matching prefix applies, must be called before Start
+func (p *P2P) SetReplication(replication []Replication) {
+	p.replication = replication
+}
+
+// replicationFor return replication policy of key
+func replicationFor(replication []Replication, key string) string {
+	for _, entry := range replication {
+		if confidential.Match(entry.Prefix, key) {
+			return entry.Policy
+		}
+	}
+	return ReplicationRoom
+}
+
+// Replicated return false if key never leaves the node
+func (p *P2P) Replicated(key string) bool {
+	return replicationFor(p.replication, key) != ReplicationLocal
+}
+
+// CheckRoom check key received in room is replicated in this room by local policies.
+// Policies may differ between peers, errors wrap ErrIgnore so mismatching keys are ignored without penalty.
+func (p *P2P) CheckRoom(room, key string) error {
+	switch replicated := p.RoomFor(key); {
+	case len(replicated) == 0:
+		return fmt.Errorf("%w: key not replicated locally", ErrIgnore)
+	case replicated != room:
+		return fmt.Errorf("%w: key not replicated in room", ErrIgnore)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package p2p
+
+import (
+	"errors"
+	"fmt"
+
+	"code.samourai.io/wallet/samourai-soroban/confidential"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+// Room is a gossipsub topic replicating keys matching prefixes.
+// The first room is the default room, replicating keys not matching any other room.
+type Room struct {
+	Name      string
+	Prefixes  []string
+	Bootstrap []string
+	// MinPeers is the count of mesh peers to wait for before publishing, zero to publish immediately
+	MinPeers int
+}
+
+// ValidateRooms check room names are unique and rooms other than default have prefixes
+func ValidateRooms(rooms []Room) error {
+	if len(rooms) == 0 {
+		return errors.New("no room")
+	}
+	names := make(map[string]bool)
+	for i, room := range rooms {
+		if len(room.Name) == 0 {
+			return errors.New("invalid room name")
+		}
+		if names[room.Name] {
+			return fmt.Errorf("duplicate room %s", room.Name)
+		}
+		names[room.Name] = true
+
+		if i > 0 && len(room.Prefixes) == 0 {
+			return fmt.Errorf("room %s without prefixes", room.Name)
+		}
+	}
+	return nil
+}
+
+// roomFor return the room name replicating key, empty if key is local
+func roomFor(rooms []Room, replication []Replication, key string) string {
+	if len(rooms) == 0 {
+		return ""
+	}
+	switch policy := replicationFor(replication, key); policy {
+	case ReplicationLocal:
+		return ""
+	case ReplicationRoom:
+	default:
+		return policy
+	}
+	for _, room := range rooms[1:] {
+		for _, prefix := range room.Prefixes {
+			if confidential.Match(prefix, key) {
+				return room.Name
+			}
+		}
+	}
+	return rooms[0].Name
+}
+
+// RoomFor return the room name replicating key, empty if key is local
+func (p *P2P) RoomFor(key string) string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return roomFor(p.rooms, p.replication, key)
+}
+
+// Rooms return joined room names, default room first
+func (p *P2P) Rooms() []string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	var result []string
+	for _, room := range p.rooms {
+		result = append(result, room.Name)
+	}
+	return result
+}
+
+// topicFor return topic of room, or default room topic if room is empty
+func (p *P2P) topicFor(room string) *pubsub.Topic {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if len(room) == 0 && len(p.rooms) > 0 {
+		room = p.rooms[0].Name
+	}
+	return p.topics[room]
+}
@@ -0,0 +1,170 @@
+package p2p
+
+import (
+	"errors"
+	"testing"
+
+	soroban "code.samourai.io/wallet/samourai-soroban"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestRoomFor(t *testing.T) {
+	rooms := []Room{
+		{Name: "samourai-p2p"},
+		{Name: "team", Prefixes: []string{"team.*"}},
+	}
+	if err := ValidateRooms(rooms); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"team.session", "team"},
+		{"samourai.register", "samourai-p2p"},
+		{"", "samourai-p2p"},
+	}
+	for _, tt := range tests {
+		if got := roomFor(rooms, nil, tt.key); got != tt.want {
+			t.Errorf("roomFor(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestValidateRooms(t *testing.T) {
+	tests := []struct {
+		name  string
+		rooms []Room
+	}{
+		{"empty", nil},
+		{"name", []Room{{Name: ""}}},
+		{"duplicate", []Room{{Name: "a"}, {Name: "a", Prefixes: []string{"a.*"}}}},
+		{"prefixes", []Room{{Name: "a"}, {Name: "b"}}},
+	}
+	for _, tt := range tests {
+		if err := ValidateRooms(tt.rooms); err == nil {
+			t.Errorf("ValidateRooms(%s) expected error", tt.name)
+		}
+	}
+}
+
+func TestReplication(t *testing.T) {
+	rooms := []Room{
+		{Name: "samourai-p2p"},
+		{Name: "team", Prefixes: []string{"team.*"}},
+	}
+	replication := []Replication{
+		{Prefix: "session.*", Policy: ReplicationLocal},
+		{Prefix: "team.public.*", Policy: "samourai-p2p"},
+		{Prefix: "shared.*", Policy: "team"},
+		{Prefix: "team.*", Policy: ReplicationRoom},
+	}
+	if err := ValidateReplication(rooms, replication); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"session.user", ""},
+		{"team.public.offers", "samourai-p2p"},
+		{"team.session", "team"},
+		{"shared.state", "team"},
+		{"samourai.register", "samourai-p2p"},
+	}
+	for _, tt := range tests {
+		if got := roomFor(rooms, replication, tt.key); got != tt.want {
+			t.Errorf("roomFor(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+
+	p2P := P2P{replication: replication}
+	if p2P.Replicated("session.user") || !p2P.Replicated("shared.state") {
+		t.Error("Replicated() local key mismatch")
+	}
+
+	invalid := [][]Replication{
+		{{Prefix: "", Policy: ReplicationLocal}},
+		{{Prefix: "a.*", Policy: ""}},
+		{{Prefix: "a.*", Policy: "unknown"}},
+	}
+	for _, replication := range invalid {
+		if err := ValidateReplication(rooms, replication); err == nil {
+			t.Errorf("ValidateReplication(%v) expected error", replication)
+		}
+	}
+}
+
+func TestReplicationMismatch(t *testing.T) {
+	rooms := []Room{
+		{Name: "samourai-p2p"},
+		{Name: "team", Prefixes: []string{"team.*"}},
+	}
+	// sender replicates session keys, receiver keeps them local
+	sender := P2P{rooms: rooms}
+	receiver := P2P{rooms: rooms, replication: []Replication{{Prefix: "session.*", Policy: ReplicationLocal}}}
+
+	validator := newMessageValidator(peer.ID("receiver"), nil, nil, func(room string, message Message) error {
+		var key string
+		if err := message.ParsePayload(&key); err != nil {
+			return err
+		}
+		return receiver.CheckRoom(room, key)
+	})
+
+	tests := []struct {
+		key     string
+		room    string
+		want    pubsub.ValidationResult
+		wantErr error
+	}{
+		{"samourai.register", "samourai-p2p", pubsub.ValidationAccept, nil},
+		{"team.offers", "team", pubsub.ValidationAccept, nil},
+		{"session.user", "samourai-p2p", pubsub.ValidationIgnore, ErrIgnore},
+		{"team.offers", "samourai-p2p", pubsub.ValidationIgnore, ErrIgnore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			room := sender.RoomFor(tt.key)
+			if tt.wantErr == nil && room != tt.room {
+				t.Fatalf("RoomFor(%q) = %v, want %v", tt.key, room, tt.room)
+			}
+			if err := receiver.CheckRoom(tt.room, tt.key); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckRoom(%q) error = %v, want %v", tt.key, err, tt.wantErr)
+			}
+
+			message, err := NewMessage("Directory.Add", tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := message.ToBytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := validator.Validate(tt.room, peer.ID("sender"), &pubsub.Message{Message: &pb.Message{Data: data}})
+			if got != tt.want {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if invalid := validator.invalidCount(peer.ID("sender")); invalid != 0 {
+		t.Errorf("Validate() invalid count = %f, want 0", invalid)
+	}
+
+	// state sync only imports items replicated in room by receiver policies
+	sent := sender.roomItems([]soroban.DirectoryItem{
+		{Name: "samourai.register", Value: "a"},
+		{Name: "session.user", Value: "b"},
+	}, "samourai-p2p")
+	if len(sent) != 2 {
+		t.Fatalf("roomItems() sent = %v", sent)
+	}
+	items := receiver.roomItems(sent, "samourai-p2p")
+	if len(items) != 1 || items[0].Name != "samourai.register" {
+		t.Errorf("roomItems() = %v", items)
+	}
+}
@@ -0,0 +1,248 @@
+package p2p
+
+import (
+	"context"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	soroban "code.samourai.io/wallet/samourai-soroban"
+	"code.samourai.io/wallet/samourai-soroban/internal/ratelimit"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+const (
+	// scoreDecayInterval between gossipsub score decays
+	scoreDecayInterval = time.Second
+	// scoreInspectDelay between score distribution updates
+	scoreInspectDelay = 30 * time.Second
+	// rateLimitedHalfLife of rate limited messages penalty
+	rateLimitedHalfLife = 10 * time.Minute
+)
+
+// ScoreStats is the distribution of gossipsub peer scores
+type ScoreStats struct {
+	Count   int
+	Min     float64
+	Max     float64
+	Mean    float64
+	Buckets map[string]int
+}
+
+type penalty struct {
+	value   float64
+	updated time.Time
+}
+
+// peerScorer compute application specific score and keep score distribution
+type peerScorer struct {
+	params  soroban.ScoreInfo
+	host    host.Host
+	limiter *ratelimit.Limiter
+
+	mutex     sync.Mutex
+	penalties map[peer.ID]*penalty
+	stats     ScoreStats
+}
+
+func newPeerScorer(ctx context.Context, h host.Host, params soroban.ScoreInfo) *peerScorer {
+	limiter := ratelimit.New()
+	go limiter.Start(ctx)
+
+	return &peerScorer{
+		params:    params,
+		host:      h,
+		limiter:   limiter,
+		penalties: make(map[peer.ID]*penalty),
+	}
+}
+
+// options return gossipsub peer score options for rooms
+func (p *peerScorer) options(rooms []Room) []pubsub.Option {
+	topics := make(map[string]*pubsub.TopicScoreParams)
+	for _, room := range rooms {
+		topics[room.Name] = &pubsub.TopicScoreParams{
+			TopicWeight:                    1,
+			TimeInMeshQuantum:              time.Second,
+			InvalidMessageDeliveriesWeight: p.params.InvalidMessageWeight,
+			InvalidMessageDeliveriesDecay:  pubsub.ScoreParameterDecay(time.Hour),
+		}
+	}
+
+	return []pubsub.Option{
+		pubsub.WithPeerScore(
+			&pubsub.PeerScoreParams{
+				Topics:                      topics,
+				AppSpecificScore:            p.appScore,
+				AppSpecificWeight:           1,
+				IPColocationFactorWeight:    p.params.ColocationWeight,
+				IPColocationFactorThreshold: p.params.ColocationThreshold,
+				// onion peers are connected from the local tor daemon
+				IPColocationFactorWhitelist: loopbackNets(),
+				DecayInterval:               scoreDecayInterval,
+				DecayToZero:                 0.01,
+				RetainScore:                 time.Hour,
+			},
+			&pubsub.PeerScoreThresholds{
+				GossipThreshold:   p.params.GossipThreshold,
+				PublishThreshold:  p.params.PublishThreshold,
+				GraylistThreshold: p.params.GraylistThreshold,
+				AcceptPXThreshold: 10,
+			},
+		),
+		pubsub.WithPeerScoreInspect(p.inspect, scoreInspectDelay),
+	}
+}
+
+func loopbackNets() []*net.IPNet {
+	var result []*net.IPNet
+	for _, cidr := range []string{"127.0.0.0/8", "::1/128"} {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		result = append(result, ipNet)
+	}
+	return result
+}
+
+// allow return false if peer exceeds message rate, and penalise peer
+func (p *peerScorer) allow(id peer.ID) bool {
+	if p.params.MessageRate <= 0 {
+		return true
+	}
+	if p.limiter.Allow(id.String(), p.params.MessageRate, p.params.MessageBurst) {
+		return true
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	entry, ok := p.penalties[id]
+	if !ok {
+		entry = &penalty{}
+		p.penalties[id] = entry
+	}
+	entry.value = decayedPenalty(entry, time.Now(), rateLimitedHalfLife) + 1
+	entry.updated = time.Now()
+	return false
+}
+
+// decayedPenalty return penalty value halved every halfLife since last update
+func decayedPenalty(entry *penalty, now time.Time, halfLife time.Duration) float64 {
+	if entry.updated.IsZero() {
+		return entry.value
+	}
+	return entry.value * math.Pow(0.5, float64(now.Sub(entry.updated))/float64(halfLife))
+}
+
+// appScore is the application specific score, from rate limited messages and onion colocation
+func (p *peerScorer) appScore(id peer.ID) float64 {
+	var score float64
+
+	p.mutex.Lock()
+	if entry, ok := p.penalties[id]; ok {
+		value := decayedPenalty(entry, time.Now(), rateLimitedHalfLife)
+		if value < 0.01 {
+			delete(p.penalties, id)
+		}
+		score += p.params.MessageRateWeight * value
+	}
+	p.mutex.Unlock()
+
+	if surplus := p.onionColocation(id) - p.params.ColocationThreshold; surplus > 0 {
+		score += p.params.ColocationWeight * float64(surplus*surplus)
+	}
+	return score
+}
+
+// onionColocation return count of connected peers sharing an onion address with peer
+func (p *peerScorer) onionColocation(id peer.ID) int {
+	onions := onionAddrs(p.host.Peerstore().Addrs(id))
+	if len(onions) == 0 {
+		return 0
+	}
+
+	count := 0
+	for _, other := range p.host.Network().Peers() {
+		for onion := range onionAddrs(p.host.Peerstore().Addrs(other)) {
+			if onions[onion] {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+func onionAddrs(addrs []multiaddr.Multiaddr) map[string]bool {
+	result := make(map[string]bool)
+	for _, addr := range addrs {
+		if value, err := addr.ValueForProtocol(multiaddr.P_ONION3); err == nil {
+			result[value] = true
+		}
+	}
+	return result
+}
+
+// inspect update score distribution
+func (p *peerScorer) inspect(scores map[peer.ID]float64) {
+	stats := ScoreStats{
+		Count: len(scores),
+		Buckets: map[string]int{
+			"below_graylist": 0,
+			"below_publish":  0,
+			"below_gossip":   0,
+			"negative":       0,
+			"neutral":        0,
+			"positive":       0,
+		},
+	}
+	var sum float64
+	first := true
+	for _, score := range scores {
+		sum += score
+		if first || score < stats.Min {
+			stats.Min = score
+		}
+		if first || score > stats.Max {
+			stats.Max = score
+		}
+		first = false
+
+		switch {
+		case score < p.params.GraylistThreshold:
+			stats.Buckets["below_graylist"]++
+		case score < p.params.PublishThreshold:
+			stats.Buckets["below_publish"]++
+		case score < p.params.GossipThreshold:
+			stats.Buckets["below_gossip"]++
+		case score < 0:
+			stats.Buckets["negative"]++
+		case score == 0:
+			stats.Buckets["neutral"]++
+		default:
+			stats.Buckets["positive"]++
+		}
+	}
+	if stats.Count > 0 {
+		stats.Mean = sum / float64(stats.Count)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.stats = stats
+}
+
+// Stats return last score distribution
+func (p *peerScorer) Stats() ScoreStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.stats
+}
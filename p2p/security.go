@@ -0,0 +1,161 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/sec"
+	"github.com/libp2p/go-libp2p/core/sec/insecure"
+	csms "github.com/libp2p/go-libp2p/p2p/net/conn-security-multistream"
+	"github.com/libp2p/go-libp2p/p2p/security/noise"
+	tls "github.com/libp2p/go-libp2p/p2p/security/tls"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Security transports, negotiated by multistream in configured order
+const (
+	SecurityNoise     = "noise"
+	SecurityTLS       = "tls"
+	SecurityPlaintext = "plaintext"
+)
+
+var (
+	// DefaultSecurity is noise only, plaintext must be enabled explicitly to accept peers not upgraded yet
+	DefaultSecurity = []string{SecurityNoise}
+)
+
+// ParseSecurity split comma separated security transports, in preference order
+func ParseSecurity(value string) ([]string, error) {
+	var result []string
+	known := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) == 0 {
+			continue
+		}
+		switch name {
+		case SecurityNoise, SecurityTLS, SecurityPlaintext:
+		default:
+			return nil, fmt.Errorf("unknown security transport %s", name)
+		}
+		if known[name] {
+			return nil, fmt.Errorf("duplicate security transport %s", name)
+		}
+		known[name] = true
+		result = append(result, name)
+	}
+	if len(result) == 0 {
+		return nil, errors.New("no security transport")
+	}
+	return result, nil
+}
+
+// SetSecurity set security transports in preference order, must be called before Start
+func (p *P2P) SetSecurity(security []string) {
+	p.security = security
+}
+
+func (p *P2P) securityTransports() []string {
+	if len(p.security) == 0 {
+		return DefaultSecurity
+	}
+	return p.security
+}
+
+// plaintextTransport wraps insecure transport, libp2p refuse to mix insecure and secure transports otherwise
+type plaintextTransport struct {
+	*insecure.Transport
+}
+
+func newPlaintextTransport(id peer.ID, key crypto.PrivKey) sec.SecureTransport {
+	return &plaintextTransport{insecure.NewWithIdentity(id, key)}
+}
+
+// SecureInbound warn when plaintext is negotiated, connection is not encrypted
+func (t *plaintextTransport) SecureInbound(ctx context.Context, insecure net.Conn, p peer.ID) (sec.SecureConn, error) {
+	conn, err := t.Transport.SecureInbound(ctx, insecure, p)
+	if err == nil {
+		warnPlaintext(conn.RemotePeer())
+	}
+	return conn, err
+}
+
+// SecureOutbound warn when plaintext is negotiated, connection is not encrypted
+func (t *plaintextTransport) SecureOutbound(ctx context.Context, insecure net.Conn, p peer.ID) (sec.SecureConn, error) {
+	conn, err := t.Transport.SecureOutbound(ctx, insecure, p)
+	if err == nil {
+		warnPlaintext(conn.RemotePeer())
+	}
+	return conn, err
+}
+
+func warnPlaintext(id peer.ID) {
+	log.WithField("PeerID", id).Warning("Plaintext p2p connection negotiated, traffic is not encrypted")
+}
+
+// hasPlaintext return true if plaintext is allowed, connections can be downgraded
+func hasPlaintext(security []string) bool {
+	for _, name := range security {
+		if name == SecurityPlaintext {
+			return true
+		}
+	}
+	return false
+}
+
+// securityOptions return libp2p options for security transports
+func securityOptions(security []string) []libp2p.Option {
+	// plaintext only, keep libp2p insecure mode
+	if len(security) == 1 && security[0] == SecurityPlaintext {
+		return []libp2p.Option{libp2p.NoSecurity}
+	}
+
+	var result []libp2p.Option
+	for _, name := range security {
+		switch name {
+		case SecurityNoise:
+			result = append(result, libp2p.Security(noise.ID, noise.New))
+		case SecurityTLS:
+			result = append(result, libp2p.Security(tls.ID, tls.New))
+		case SecurityPlaintext:
+			result = append(result, libp2p.Security(insecure.ID, newPlaintextTransport))
+		}
+	}
+	return result
+}
+
+// securityMuxer return security transports muxer, for transports upgrading connections outside of libp2p config
+func securityMuxer(key crypto.PrivKey, security []string) (sec.SecureMuxer, error) {
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(csms.SSMuxer)
+	for _, name := range security {
+		switch name {
+		case SecurityNoise:
+			transport, err := noise.New(key)
+			if err != nil {
+				return nil, err
+			}
+			result.AddTransport(noise.ID, transport)
+		case SecurityTLS:
+			transport, err := tls.New(key)
+			if err != nil {
+				return nil, err
+			}
+			result.AddTransport(tls.ID, transport)
+		case SecurityPlaintext:
+			result.AddTransport(insecure.ID, newPlaintextTransport(id, key))
+		}
+	}
+	return result, nil
+}
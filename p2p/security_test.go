@@ -0,0 +1,80 @@
+package p2p
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestParseSecurity(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{"noise", []string{"noise"}, false},
+		{" Noise, tls ,plaintext", []string{"noise", "tls", "plaintext"}, false},
+		{"", nil, true},
+		{"noise,noise", nil, true},
+		{"ssl", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSecurity(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSecurity(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSecurity(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func newSecurityHost(t *testing.T, security []string) host.Host {
+	opts := []libp2p.Option{
+		libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"),
+		libp2p.DefaultTransports,
+		libp2p.DefaultMuxers,
+		libp2p.RandomIdentity,
+		libp2p.DefaultPeerstore,
+	}
+	h, err := libp2p.NewWithoutDefaults(append(opts, securityOptions(security)...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Close() })
+	return h
+}
+
+func TestSecurityNegotiation(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialer  []string
+		remote  []string
+		wantErr bool
+	}{
+		{"noise", []string{"noise"}, []string{"noise", "plaintext"}, false},
+		{"tls", []string{"tls"}, []string{"noise", "tls"}, false},
+		{"mixed", []string{"noise", "plaintext"}, []string{"plaintext"}, false},
+		{"plaintext refused", []string{"plaintext"}, []string{"noise"}, true},
+		{"default refuse plaintext", []string{"plaintext"}, DefaultSecurity, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialer := newSecurityHost(t, tt.dialer)
+			remote := newSecurityHost(t, tt.remote)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			err := dialer.Connect(ctx, peer.AddrInfo{ID: remote.ID(), Addrs: remote.Addrs()})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Connect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package p2p
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSeenTTL is the duration message IDs are remembered
+	DefaultSeenTTL = 10 * time.Minute
+)
+
+// SeenCache remember message IDs to drop duplicates and prevent forwarding loops
+type SeenCache struct {
+	ttl time.Duration
+
+	mutex       sync.Mutex
+	entries     map[string]time.Time
+	lastCleanup time.Time
+}
+
+func NewSeenCache(ttl time.Duration) *SeenCache {
+	return &SeenCache{
+		ttl:         ttl,
+		entries:     make(map[string]time.Time),
+		lastCleanup: time.Now(),
+	}
+}
+
+// Seen mark message ID as seen, return true if it was already seen.
+// Empty IDs, from peers without message IDs, are never seen.
+func (p *SeenCache) Seen(id string) bool {
+	if len(id) == 0 {
+		return false
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(p.lastCleanup) > p.ttl {
+		for key, expireOn := range p.entries {
+			if now.After(expireOn) {
+				delete(p.entries, key)
+			}
+		}
+		p.lastCleanup = now
+	}
+
+	if expireOn, ok := p.entries[id]; ok && now.Before(expireOn) {
+		return true
+	}
+	p.entries[id] = now.Add(p.ttl)
+	return false
+}
+
+// Len return count of remembered IDs
+func (p *SeenCache) Len() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return len(p.entries)
+}
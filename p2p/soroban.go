@@ -2,13 +2,16 @@ package p2p
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"sync"
 	"time"
 
+	soroban "code.samourai.io/wallet/samourai-soroban"
 	"github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p-core/network"
+	dht "github.com/libp2p/go-libp2p-kad-dht"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/p2p/net/swarm"
 	"github.com/multiformats/go-multiaddr"
@@ -18,23 +21,118 @@ import (
 
 // P2P for distributed soroban
 type P2P struct {
-	topic     *pubsub.Topic
+	mutex     sync.RWMutex
+	rooms     []Room
+	topics    map[string]*pubsub.Topic
+	gossipSub *pubsub.PubSub
+	bootstrap []peer.AddrInfo
+	host      host.Host
+	kdht      *dht.IpfsDHT
+	mesh      *meshTracer
+	seen      *SeenCache
+	peerBook  *PeerBook
+	banList   *BanList
+	keyFile   string
+	security  []string
+	encoding  string
+	score     *soroban.ScoreInfo
+	scorer    *peerScorer
+	sync      soroban.DirectorySync
+	validator Validator
+	queue     *MessageQueue
 	OnMessage chan Message
+
+	lookupValidator LookupValidator
+	replication     []Replication
+	subscriptions   map[string]*pubsub.Subscription
+	pending         []pendingMessage
+	state           State
+	stateSince      time.Time
+	recoveries      int
+
+	health        map[peer.ID]PeerHealth
+	heartbeats    map[peer.ID]time.Time
+	lastHeartbeat time.Time
+	heartbeatSent time.Time
 }
 
 func (p *P2P) Valid() bool {
-	return p.topic != nil
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return len(p.topics) > 0
+}
+
+// SetPeerBook set peer book used to reconnect to known peers, must be called before Start
+func (p *P2P) SetPeerBook(peerBook *PeerBook) {
+	p.peerBook = peerBook
+}
+
+// SetKeyFile set file of persistent p2p identity, created if missing, must be called before Start.
+// Key file takes precedence over seed.
+func (p *P2P) SetKeyFile(keyFile string) {
+	p.keyFile = keyFile
+}
+
+// SetBanList set ban list used for banned and blacklisted peers, must be called before Start
+func (p *P2P) SetBanList(banList *BanList) {
+	p.banList = banList
+}
+
+// BanList return ban list, nil if not set
+func (p *P2P) BanList() *BanList {
+	return p.banList
+}
+
+// SetScore enable gossipsub peer scoring, must be called before Start
+func (p *P2P) SetScore(params soroban.ScoreInfo) {
+	if params.Disabled {
+		p.score = nil
+		return
+	}
+	p.score = &params
+}
+
+// SetQueue set bounded queue of inbound messages, must be called before Start.
+// Messages are sent to OnMessage channel if not set.
+func (p *P2P) SetQueue(queue *MessageQueue) {
+	p.queue = queue
+}
+
+// Queue return inbound message queue, nil if not set
+func (p *P2P) Queue() *MessageQueue {
+	return p.queue
 }
 
-func (p *P2P) Start(ctx context.Context, p2pSeed string, hostname string, listenPort int, bootstrap, room string, ready chan struct{}) error {
+// Start p2p host and join rooms, the first room is the default room
+func (p *P2P) Start(ctx context.Context, p2pSeed string, hostname string, listenPort int, rooms []Room, ready chan struct{}) error {
 	ctx = network.WithDialPeerTimeout(ctx, 3*time.Minute)
 	defer func() {
 		ready <- struct{}{}
 	}()
 
+	if err := ValidateRooms(rooms); err != nil {
+		return err
+	}
+	if err := ValidateReplication(rooms, p.replication); err != nil {
+		return err
+	}
+	// messages published before rooms are joined are pending
+	p.mutex.Lock()
+	p.rooms = rooms
+	p.setStateLocked(StateStarting)
+	p.mutex.Unlock()
+
 	var opts []libp2p.Option
-	if len(p2pSeed) > 0 {
-		p2pOpts, err := initTorP2P(ctx, p2pSeed, listenPort)
+	if len(p2pSeed) > 0 || len(p.keyFile) > 0 {
+		priv, err := LoadIdentity(p2pSeed, p.keyFile)
+		if err != nil {
+			return err
+		}
+		if p2pSeed == "auto" && len(p.keyFile) == 0 {
+			log.Warning("P2P ephemeral identity, peer ID and onion address change on restart")
+		}
+		p2pOpts, err := initTorP2P(ctx, priv, listenPort, p.securityTransports())
 		if err != nil {
 			return err
 		}
@@ -48,12 +146,23 @@ func (p *P2P) Start(ctx context.Context, p2pSeed string, hostname string, listen
 			libp2p.ListenAddrStrings(fmt.Sprintf("/ip4/%s/tcp/%d", hostname, listenPort)),
 			libp2p.DefaultTransports,
 			libp2p.DefaultMuxers,
-			libp2p.NoSecurity,
 			libp2p.RandomIdentity,
 			libp2p.DefaultPeerstore,
 			libp2p.DefaultMultiaddrResolver,
 		}...)
+		opts = append(opts, securityOptions(p.securityTransports())...)
 	}
+	log.WithField("Security", p.securityTransports()).Info("P2P security transports")
+	if hasPlaintext(p.securityTransports()) {
+		log.Warning("P2P plaintext security enabled, connections can be downgraded to plaintext by peers")
+	}
+	log.WithField("Encoding", p.encoding).Info("P2P message encoding")
+
+	// refuse connections from banned peers
+	if p.banList == nil {
+		p.banList, _ = LoadBanList("")
+	}
+	opts = append(opts, libp2p.ConnectionGater(p.banList))
 
 	// create the swarm
 	swarm.BackoffBase = 30 * time.Second
@@ -66,40 +175,102 @@ func (p *P2P) Start(ctx context.Context, p2pSeed string, hostname string, listen
 		log.WithField("Addr", addr.String()).Info("P2P addr")
 	}
 
+	// bootstrap with peers of all rooms and known peers
 	addrs := []multiaddr.Multiaddr{}
-	addr, err := multiaddr.NewMultiaddr(bootstrap)
-	if err != nil {
-		return err
+	for _, room := range rooms {
+		for _, bootstrap := range room.Bootstrap {
+			addr, err := multiaddr.NewMultiaddr(bootstrap)
+			if err != nil {
+				log.WithError(err).WithField("Bootstrap", bootstrap).Error("Invalid bootstrap address")
+				continue
+			}
+			addrs = append(addrs, addr)
+		}
+	}
+	var knownPeers []peer.AddrInfo
+	if p.peerBook != nil {
+		knownPeers = p.peerBook.AddrInfos()
+		go p.peerBook.Start(ctx)
 	}
-	addrs = append(addrs, addr)
-	dht, err := NewDHT(ctx, host, addrs...)
+	kdht, err := NewDHT(ctx, host, addrs, knownPeers)
 	if err != nil {
 		return err
 	}
 
-	discoverReady := make(chan struct{})
-	go Discover(ctx, host, dht, room, discoverReady)
-	<-discoverReady
+	for _, room := range rooms {
+		discoverReady := make(chan struct{})
+		go Discover(ctx, host, kdht, room.Name, p.peerBook, discoverReady)
+		<-discoverReady
+	}
 
-	gossipSub, err := pubsub.NewGossipSub(ctx, host)
+	// disconnect peers when banned
+	p.banList.setOnBan(func(id peer.ID) {
+		if host.Network().Connectedness(id) == network.Connected {
+			log.WithField("PeerID", id).Info("Closing connection with banned peer")
+			host.Network().ClosePeer(id)
+		}
+	})
+	go p.banList.Start(ctx)
+
+	mesh := newMeshTracer()
+	pubsubOpts := []pubsub.Option{
+		pubsub.WithBlacklist(p.banList),
+		pubsub.WithMessageIdFn(messageID),
+		pubsub.WithRawTracer(mesh),
+	}
+	var scorer *peerScorer
+	if p.score != nil {
+		scorer = newPeerScorer(ctx, host, *p.score)
+		pubsubOpts = append(pubsubOpts, scorer.options(rooms)...)
+	}
+	gossipSub, err := pubsub.NewGossipSub(ctx, host, pubsubOpts...)
 	if err != nil {
 		return err
 	}
 
-	topic, err := gossipSub.Join(room)
-	if err != nil {
-		return err
+	// validate messages before delivering and relaying them
+	validator := newMessageValidator(host.ID(), gossipSub, scorer, p.validator)
+
+	topics := make(map[string]*pubsub.Topic)
+	for _, room := range rooms {
+		err = gossipSub.RegisterTopicValidator(room.Name, validator.TopicValidator(room.Name))
+		if err != nil {
+			return err
+		}
+		topic, err := gossipSub.Join(room.Name)
+		if err != nil {
+			return err
+		}
+		topics[room.Name] = topic
+		log.WithField("Room", room.Name).WithField("Prefixes", room.Prefixes).Info("P2P room joined")
 	}
 
-	p.topic = topic
+	p.mutex.Lock()
+	p.topics = topics
+	p.subscriptions = make(map[string]*pubsub.Subscription)
+	p.host = host
+	p.kdht = kdht
+	p.gossipSub = gossipSub
+	p.bootstrap = bootstrapAddrInfos(addrs)
+	p.mesh = mesh
+	p.seen = NewSeenCache(DefaultSeenTTL)
+	p.scorer = scorer
+	p.mutex.Unlock()
 
-	// subscribe to topic
-	subscriber, err := topic.Subscribe()
-	if err != nil {
-		return err
+	// subscribe to topics
+	for room, topic := range topics {
+		if err := p.subscribeRoom(ctx, room, topic); err != nil {
+			return err
+		}
 	}
+	go p.flushPending(ctx)
 
-	go p.subscribe(ctx, subscriber, host.ID())
+	// serve and pull directory state from peers
+	p.startSync(ctx, host)
+	// heartbeats and peer health
+	p.startLiveness(ctx, host)
+	// federated lookups from peers
+	p.startLookup(host)
 	return nil
 }
 
@@ -107,6 +278,9 @@ func (p *P2P) Start(ctx context.Context, p2pSeed string, hostname string, listen
 func (p *P2P) subscribe(ctx context.Context, subscriber *pubsub.Subscription, hostID peer.ID) {
 	for {
 		msg, err := subscriber.Next(ctx)
+		if err == pubsub.ErrSubscriptionCancelled || ctx.Err() != nil {
+			return
+		}
 		if err != nil {
 			log.Printf("failed to get next message")
 			<-time.After(time.Second)
@@ -123,34 +297,68 @@ func (p *P2P) subscribe(ctx context.Context, subscriber *pubsub.Subscription, ho
 			log.Debug("Skip unkown message")
 			continue
 		}
+		message.From = msg.GetFrom()
+		// drop duplicates
+		if p.seen.Seen(message.Hash()) {
+			log.WithField("ID", message.ID).Trace("Skip duplicate message")
+			continue
+		}
+		forwarded, err := message.Forward()
+		if err != nil {
+			log.WithError(err).WithField("ID", message.ID).Debug("Skip message")
+			continue
+		}
 
-		p.OnMessage <- message
+		if p.queue == nil {
+			p.OnMessage <- forwarded
+			continue
+		}
+		if !p.queue.Push(forwarded) {
+			log.WithField("ID", message.ID).Debug("Inbound queue full, message dropped")
+		}
 	}
 }
 
-// Publish to topic
+// Publish to default room topic
 func (p *P2P) Publish(ctx context.Context, msg string) error {
-	if len(msg) == 0 {
-		return errors.New("failed to publish empty message")
-	}
-	if p.topic == nil {
-		return nil
-	}
-	p.topic.Publish(ctx, []byte(msg))
-	return nil
+	return p.publish(ctx, "", msg)
 }
 
-// Publish to topic
+// Publish to default room topic
 func (p *P2P) PublishJson(ctx context.Context, context string, payload interface{}) error {
 	message, err := NewMessage(context, payload)
 	if err != nil {
 		return err
 	}
 
-	data, err := message.ToBytes()
+	return p.PublishMessage(ctx, "", message)
+}
+
+// PublishMessage publish message to room topic, keeping message ID.
+// Default room is used if room is empty, Origin is set to local peer ID for new messages.
+func (p *P2P) PublishMessage(ctx context.Context, room string, message Message) error {
+	p.mutex.RLock()
+	host, seen := p.host, p.seen
+	p.mutex.RUnlock()
+
+	if host != nil && len(message.Origin) == 0 {
+		message.Origin = host.ID().String()
+	}
+	// messages published are not processed again when relayed back
+	if seen != nil {
+		seen.Seen(message.Hash())
+	}
+
+	var data []byte
+	var err error
+	if p.messageEncoding() == EncodingBinary {
+		data, err = message.ToEnvelope()
+	} else {
+		data, err = message.ToBytes()
+	}
 	if err != nil {
 		return err
 	}
 
-	return p.Publish(ctx, string(data))
+	return p.publish(ctx, room, string(data))
 }
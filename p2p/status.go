@@ -0,0 +1,55 @@
+package p2p
+
+import (
+	"time"
+)
+
+// Status of p2p node, reported by children to IPC server
+type Status struct {
+	PeerID     string
+	State      State
+	StateSince time.Time
+	Recoveries int
+	Rooms      []string
+	Scores     *ScoreStats  `json:",omitempty"`
+	Health     *HealthStats `json:",omitempty"`
+	Queue      *QueueStats  `json:",omitempty"`
+	Pending    int
+	Banned     int
+	UpdatedAt  time.Time
+}
+
+// Status return current p2p status
+func (p *P2P) Status() Status {
+	p.mutex.RLock()
+	host, scorer := p.host, p.scorer
+	state, stateSince, recoveries := p.state, p.stateSince, p.recoveries
+	health := p.healthStats()
+	pending := len(p.pending)
+	p.mutex.RUnlock()
+
+	result := Status{
+		State:      state,
+		StateSince: stateSince,
+		Recoveries: recoveries,
+		Health:     health,
+		Pending:    pending,
+		Rooms:      p.Rooms(),
+		UpdatedAt:  time.Now().UTC(),
+	}
+	if host != nil {
+		result.PeerID = host.ID().String()
+	}
+	if scorer != nil {
+		stats := scorer.Stats()
+		result.Scores = &stats
+	}
+	if p.queue != nil {
+		stats := p.queue.Stats()
+		result.Queue = &stats
+	}
+	if p.banList != nil {
+		result.Banned = len(p.banList.Entries())
+	}
+	return result
+}
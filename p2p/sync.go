@@ -0,0 +1,345 @@
+package p2p
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"time"
+
+	soroban "code.samourai.io/wallet/samourai-soroban"
+	"code.samourai.io/wallet/samourai-soroban/confidential"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// SyncProtocol is the request/response protocol used for anti-entropy state sync
+	SyncProtocol = protocol.ID("/soroban/sync/1.0.0")
+
+	syncTypeDigest   = "digest"
+	syncTypeSnapshot = "snapshot"
+
+	syncMaxSize        = 8 * 1024 * 1024
+	syncPageKeys       = 1000
+	syncMaxPages       = 1000
+	syncStreamTimeout  = 2 * time.Minute
+	syncInitialPeers   = 3
+	syncInitialTimeout = 5 * time.Minute
+	syncDigestDelay    = 5 * time.Minute
+)
+
+type syncRequest struct {
+	Type string
+	Room string `json:",omitempty"`
+	// After is the last key of previous snapshot page
+	After string `json:",omitempty"`
+}
+
+type syncResponse struct {
+	Digest string                  `json:",omitempty"`
+	Items  []soroban.DirectoryItem `json:",omitempty"`
+	// Next is set when more snapshot pages are available
+	Next string `json:",omitempty"`
+}
+
+// SetDirectorySync set the directory used for state sync, must be called before Start
+func (p *P2P) SetDirectorySync(directory soroban.DirectorySync) {
+	p.sync = directory
+}
+
+// SyncDigest return a digest of directory items.
+// Expiration dates are not part of the digest, they differ slightly between peers.
+func SyncDigest(items []soroban.DirectoryItem) string {
+	hash := sha256.New()
+	for _, item := range items {
+		hash.Write([]byte(item.Key))
+		hash.Write([]byte{0})
+		hash.Write([]byte(item.Value))
+		hash.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (p *P2P) startSync(ctx context.Context, h host.Host) {
+	if p.sync == nil {
+		return // Noop
+	}
+	h.SetStreamHandler(SyncProtocol, func(stream network.Stream) {
+		p.handleSync(stream)
+	})
+
+	for _, room := range p.Rooms() {
+		go func(room string) {
+			// pull snapshot from first peers of room when joining
+			p.syncInitial(ctx, h, room)
+
+			// then compare digests periodically to repair divergences
+			for {
+				select {
+				case <-time.After(syncDigestDelay):
+					peers := p.roomPeers(room)
+					if len(peers) == 0 {
+						continue
+					}
+					peerID := peers[rand.Intn(len(peers))]
+					err := p.syncRepair(ctx, h, peerID, room)
+					if err != nil {
+						log.WithError(err).WithField("PeerID", peerID).WithField("Room", room).Debug("State sync repair failed")
+					}
+
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(room)
+	}
+}
+
+// roomPeers return peers subscribed to room topic
+func (p *P2P) roomPeers(room string) []peer.ID {
+	topic := p.topicFor(room)
+	if topic == nil {
+		return nil
+	}
+	return topic.ListPeers()
+}
+
+func containsPeer(peers []peer.ID, id peer.ID) bool {
+	for _, peerID := range peers {
+		if peerID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// syncRoom return room name, default room if empty
+func (p *P2P) syncRoom(room string) string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if len(room) == 0 && len(p.rooms) > 0 {
+		return p.rooms[0].Name
+	}
+	return room
+}
+
+// roomItems return items replicated in room by state sync.
+// Items don't carry signed requests, entries of confidential or readonly keys are never synced.
+func (p *P2P) roomItems(items []soroban.DirectoryItem, room string) []soroban.DirectoryItem {
+	room = p.syncRoom(room)
+
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	var result []soroban.DirectoryItem
+	for _, item := range items {
+		if len(item.Name) == 0 || confidential.Protected(item.Name) {
+			continue
+		}
+		if roomFor(p.rooms, p.replication, item.Name) == room {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// roomDigest return digest of local items replicated in room, directory is exported by pages
+func (p *P2P) roomDigest(room string) (string, error) {
+	var items []soroban.DirectoryItem
+	after := ""
+	for page := 0; page < syncMaxPages; page++ {
+		exported, err := p.sync.Export(after, syncPageKeys)
+		if err != nil {
+			return "", err
+		}
+		items = append(items, p.roomItems(exported, room)...)
+
+		after = nextKey(exported, syncPageKeys)
+		if len(after) == 0 {
+			return SyncDigest(items), nil
+		}
+	}
+	return "", errors.New("too many pages")
+}
+
+// nextKey return last key of page if page is full, empty otherwise
+func nextKey(items []soroban.DirectoryItem, limit int) string {
+	count := 0
+	last := ""
+	for _, item := range items {
+		if item.Key != last {
+			count++
+			last = item.Key
+		}
+	}
+	if count < limit {
+		return ""
+	}
+	return last
+}
+
+func (p *P2P) syncInitial(ctx context.Context, h host.Host, room string) {
+	deadline := time.Now().Add(syncInitialTimeout)
+	synced := make(map[peer.ID]bool)
+	for len(synced) < syncInitialPeers && time.Now().Before(deadline) {
+		for _, peerID := range p.roomPeers(room) {
+			if synced[peerID] || len(synced) >= syncInitialPeers {
+				continue
+			}
+			count, err := p.syncSnapshot(ctx, h, peerID, room)
+			if err != nil {
+				log.WithError(err).WithField("PeerID", peerID).WithField("Room", room).Debug("State sync failed")
+				continue
+			}
+			synced[peerID] = true
+			log.WithField("PeerID", peerID).WithField("Room", room).WithField("Count", count).Info("State sync snapshot imported")
+		}
+
+		select {
+		case <-time.After(10 * time.Second):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// syncRepair pull snapshot from peer if room digests differ
+func (p *P2P) syncRepair(ctx context.Context, h host.Host, peerID peer.ID, room string) error {
+	digest, err := p.roomDigest(room)
+	if err != nil {
+		return err
+	}
+	resp, err := syncRequestPeer(ctx, h, peerID, syncRequest{Type: syncTypeDigest, Room: room})
+	if err != nil {
+		return err
+	}
+	if resp.Digest == digest {
+		return nil
+	}
+
+	count, err := p.syncSnapshot(ctx, h, peerID, room)
+	if err != nil {
+		return err
+	}
+	log.WithField("PeerID", peerID).WithField("Room", room).WithField("Count", count).Info("State sync repaired divergence")
+	return nil
+}
+
+// syncSnapshot pull snapshot from peer, page by page
+func (p *P2P) syncSnapshot(ctx context.Context, h host.Host, peerID peer.ID, room string) (int, error) {
+	count := 0
+	after := ""
+	for page := 0; page < syncMaxPages; page++ {
+		resp, err := syncRequestPeer(ctx, h, peerID, syncRequest{Type: syncTypeSnapshot, Room: room, After: after})
+		if err != nil {
+			return count, err
+		}
+		// only import items replicated in room
+		items := p.roomItems(resp.Items, room)
+		err = p.sync.Import(items)
+		if err != nil {
+			return count, err
+		}
+		count += len(items)
+
+		if len(resp.Next) == 0 {
+			return count, nil
+		}
+		if resp.Next <= after {
+			return count, errors.New("invalid snapshot page")
+		}
+		after = resp.Next
+	}
+	return count, errors.New("too many snapshot pages")
+}
+
+func syncRequestPeer(ctx context.Context, h host.Host, peerID peer.ID, request syncRequest) (syncResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, syncStreamTimeout)
+	defer cancel()
+
+	stream, err := h.NewStream(ctx, peerID, SyncProtocol)
+	if err != nil {
+		return syncResponse{}, err
+	}
+	defer stream.Close()
+	stream.SetDeadline(time.Now().Add(syncStreamTimeout))
+
+	err = json.NewEncoder(stream).Encode(&request)
+	if err != nil {
+		stream.Reset()
+		return syncResponse{}, err
+	}
+	err = stream.CloseWrite()
+	if err != nil {
+		stream.Reset()
+		return syncResponse{}, err
+	}
+
+	var resp syncResponse
+	err = json.NewDecoder(io.LimitReader(stream, syncMaxSize)).Decode(&resp)
+	if err != nil {
+		stream.Reset()
+		return syncResponse{}, err
+	}
+	return resp, nil
+}
+
+func (p *P2P) handleSync(stream network.Stream) {
+	defer stream.Close()
+	stream.SetDeadline(time.Now().Add(syncStreamTimeout))
+
+	var request syncRequest
+	err := json.NewDecoder(io.LimitReader(stream, 1024)).Decode(&request)
+	if err != nil {
+		stream.Reset()
+		return
+	}
+
+	// entries must not leak between rooms, only room members can sync
+	peerID := stream.Conn().RemotePeer()
+	room := p.syncRoom(request.Room)
+	if !containsPeer(p.roomPeers(room), peerID) {
+		log.WithField("PeerID", peerID).WithField("Room", room).Debug("State sync refused, peer not in room")
+		stream.Reset()
+		return
+	}
+
+	var resp syncResponse
+	switch request.Type {
+	case syncTypeDigest:
+		resp.Digest, err = p.roomDigest(room)
+
+	case syncTypeSnapshot:
+		var items []soroban.DirectoryItem
+		items, err = p.sync.Export(request.After, syncPageKeys)
+		if err == nil {
+			resp.Items = p.roomItems(items, room)
+			resp.Next = nextKey(items, syncPageKeys)
+		}
+
+	default:
+		log.WithField("PeerID", peerID).WithField("Type", request.Type).Debug("Unknown state sync request")
+		stream.Reset()
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("Failed to export directory")
+		stream.Reset()
+		return
+	}
+
+	err = json.NewEncoder(stream).Encode(&resp)
+	if err != nil {
+		stream.Reset()
+		return
+	}
+}
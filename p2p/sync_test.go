@@ -0,0 +1,78 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	soroban "code.samourai.io/wallet/samourai-soroban"
+	"code.samourai.io/wallet/samourai-soroban/confidential"
+	"code.samourai.io/wallet/samourai-soroban/internal/memory"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestRoomItems(t *testing.T) {
+	defer confidential.SetConfig(confidential.Config())
+	confidential.SetConfig(confidential.SorobanConfig{
+		Confidential: []confidential.ConfidentialEntry{
+			{Prefix: "signed.*", ReadOnly: true},
+			{Prefix: "secret.*", Confidential: true},
+		},
+	})
+
+	p := P2P{
+		rooms: []Room{{Name: "default"}, {Name: "team", Prefixes: []string{"team.*"}}},
+	}
+	items := []soroban.DirectoryItem{
+		{Name: "key", Value: "a"},
+		{Name: "team.key", Value: "b"},
+		{Name: "signed.key", Value: "c"},
+		{Name: "secret.key", Value: "d"},
+		{Value: "e"},
+	}
+
+	result := p.roomItems(items, "")
+	if len(result) != 1 || result[0].Value != "a" {
+		t.Errorf("roomItems() = %v, want [a]", result)
+	}
+	result = p.roomItems(items, "team")
+	if len(result) != 1 || result[0].Value != "b" {
+		t.Errorf("roomItems() team = %v, want [b]", result)
+	}
+}
+
+func TestNextKey(t *testing.T) {
+	items := []soroban.DirectoryItem{{Key: "a"}, {Key: "a"}, {Key: "b"}}
+	if next := nextKey(items, 2); next != "b" {
+		t.Errorf("nextKey() = %s, want b", next)
+	}
+	if next := nextKey(items, 3); len(next) != 0 {
+		t.Errorf("nextKey() = %s, want last page", next)
+	}
+}
+
+func TestSyncRefusedOutsideRoom(t *testing.T) {
+	directory := memory.NewWithDomain("test", memory.DefaultCacheCapacity, memory.DefaultCacheTTL)
+	directory.Add("key", "a", time.Minute)
+
+	// requester is not subscribed to room topic
+	remote := P2P{
+		rooms: []Room{{Name: "default"}},
+		sync:  directory,
+	}
+	dialer := newSecurityHost(t, DefaultSecurity)
+	remoteHost := newSecurityHost(t, DefaultSecurity)
+	remoteHost.SetStreamHandler(SyncProtocol, remote.handleSync)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := dialer.Connect(ctx, peer.AddrInfo{ID: remoteHost.ID(), Addrs: remoteHost.Addrs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := syncRequestPeer(ctx, dialer, remoteHost.ID(), syncRequest{Type: syncTypeSnapshot, Room: "default"})
+	if err == nil {
+		t.Errorf("syncRequestPeer() = %v, want refused", resp)
+	}
+}
@@ -2,9 +2,6 @@ package p2p
 
 import (
 	"context"
-	"crypto/ed25519"
-	"crypto/rand"
-	"encoding/hex"
 	"fmt"
 	"io"
 	"time"
@@ -21,31 +18,15 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-func initTorP2P(ctx context.Context, p2pSeed string, listenPort int) ([]libp2p.Option, error) {
+func initTorP2P(ctx context.Context, priv crypto.PrivKey, listenPort int, security []string) ([]libp2p.Option, error) {
 	extraArgs := []string{
 		// "--DNSPort", "2121",
 	}
 
-	var privateKey ed25519.PrivateKey
-	var priv crypto.PrivKey
-	if len(p2pSeed) > 0 {
-		if p2pSeed == "auto" {
-			_, pri, err := ed25519.GenerateKey(rand.Reader)
-			if err != nil {
-				return nil, err
-			}
-
-			p2pSeed = hex.EncodeToString(pri.Seed())
-		}
-		data, err := hex.DecodeString(p2pSeed)
-		if err != nil {
-			return nil, err
-		}
-		priv, err = crypto.UnmarshalSecp256k1PrivateKey(data)
-		if err != nil {
-			return nil, err
-		}
-		privateKey = ed25519.NewKeyFromSeed(data)
+	// onion address is derived from p2p identity
+	privateKey, err := onionKey(priv)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create the embedded Tor client.
@@ -105,17 +86,24 @@ func initTorP2P(ctx context.Context, p2pSeed string, listenPort int) ([]libp2p.O
 		return nil, err
 	}
 
-	return []libp2p.Option{
+	// outbound connections are upgraded by the onion transport
+	secMuxer, err := securityMuxer(priv, security)
+	if err != nil {
+		log.WithError(err).Error("Failed to create security muxer")
+		return nil, err
+	}
+
+	opts := []libp2p.Option{
 		libp2p.Identity(priv),
 
 		libp2p.ListenAddrs(onionAddr),
-		libp2p.Transport(onion.NewOnionTransportC(priv, dialer, onionService, dialOnlyOnion)),
+		libp2p.Transport(onion.NewOnionTransportC(priv, dialer, onionService, dialOnlyOnion, secMuxer)),
 		libp2p.DefaultMuxers,
 		libp2p.DefaultPeerstore,
 
-		libp2p.NoSecurity,
 		libp2p.WithDialTimeout(5 * time.Minute),
 		libp2p.EnableRelay(),
 		libp2p.EnableAutoRelay(),
-	}, nil
+	}
+	return append(opts, securityOptions(security)...), nil
 }
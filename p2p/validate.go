@@ -0,0 +1,133 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// maxInvalidMessages before peer is blacklisted, count decays over time
+	maxInvalidMessages = 10
+	// invalidHalfLife of invalid messages count, sporadic invalid messages never blacklist a peer
+	invalidHalfLife = 10 * time.Minute
+)
+
+var (
+	// ErrIgnore is wrapped by validator errors when message is not applicable locally, like config mismatches between peers.
+	// Ignored messages are neither delivered nor relayed, without penalty for the peer.
+	ErrIgnore = errors.New("message ignored")
+)
+
+// Validator check message received in room before it is delivered and relayed to other peers.
+// Errors wrapping ErrIgnore ignore message, other errors reject it.
+type Validator func(room string, message Message) error
+
+// SetValidator set topic message validator, must be called before Start
+func (p *P2P) SetValidator(validator Validator) {
+	p.validator = validator
+}
+
+type messageValidator struct {
+	hostID    peer.ID
+	validator Validator
+	gossipSub *pubsub.PubSub
+	scorer    *peerScorer
+
+	mutex   sync.Mutex
+	invalid map[peer.ID]*penalty
+}
+
+func newMessageValidator(hostID peer.ID, gossipSub *pubsub.PubSub, scorer *peerScorer, validator Validator) *messageValidator {
+	return &messageValidator{
+		hostID:    hostID,
+		gossipSub: gossipSub,
+		scorer:    scorer,
+		validator: validator,
+		invalid:   make(map[peer.ID]*penalty),
+	}
+}
+
+// TopicValidator return gossipsub topic validator for room
+func (p *messageValidator) TopicValidator(room string) pubsub.ValidatorEx {
+	return func(ctx context.Context, peerID peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+		return p.Validate(room, peerID, msg)
+	}
+}
+
+// Validate message received in room.
+// Rejected messages are not relayed and penalise the peer which delivered them, ignored messages are only dropped.
+func (p *messageValidator) Validate(room string, peerID peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	// local messages are validated before publishing
+	if peerID == p.hostID {
+		return pubsub.ValidationAccept
+	}
+
+	// ignore messages above rate limit, peer score is penalised
+	if p.scorer != nil && !p.scorer.allow(peerID) {
+		log.WithField("PeerID", peerID).WithField("Room", room).Debug("Ignored rate limited p2p message")
+		return pubsub.ValidationIgnore
+	}
+
+	message, err := MessageFromBytes(msg.Data)
+	if err == nil && p.validator != nil {
+		err = p.validator(room, message)
+	}
+	if errors.Is(err, ErrIgnore) {
+		log.WithError(err).WithField("PeerID", peerID).WithField("Room", room).WithField("Context", message.Context).Debug("Ignored p2p message")
+		return pubsub.ValidationIgnore
+	}
+	if err != nil {
+		log.WithError(err).WithField("PeerID", peerID).WithField("Room", room).WithField("Context", message.Context).Debug("Rejected invalid p2p message")
+		p.penalise(peerID)
+		return pubsub.ValidationReject
+	}
+	return pubsub.ValidationAccept
+}
+
+// penalise count invalid message of peer, peer is blacklisted when decayed count reaches maxInvalidMessages
+func (p *messageValidator) penalise(peerID peer.ID) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	// forget peers whose count decayed
+	for id, entry := range p.invalid {
+		if decayedPenalty(entry, now, invalidHalfLife) < 0.01 {
+			delete(p.invalid, id)
+		}
+	}
+
+	entry, ok := p.invalid[peerID]
+	if !ok {
+		entry = &penalty{}
+		p.invalid[peerID] = entry
+	}
+	entry.value = decayedPenalty(entry, now, invalidHalfLife) + 1
+	entry.updated = now
+	if entry.value < maxInvalidMessages {
+		return
+	}
+	delete(p.invalid, peerID)
+
+	log.WithField("PeerID", peerID).Warning("Too many invalid messages, blacklisting peer")
+	p.gossipSub.BlacklistPeer(peerID)
+}
+
+// invalidCount return decayed invalid messages count of peer
+func (p *messageValidator) invalidCount(peerID peer.ID) float64 {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	entry, ok := p.invalid[peerID]
+	if !ok {
+		return 0
+	}
+	return decayedPenalty(entry, time.Now(), invalidHalfLife)
+}
@@ -0,0 +1,74 @@
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestMessageValidator(t *testing.T) {
+	validator := newMessageValidator(peer.ID("local"), nil, nil, func(room string, message Message) error {
+		switch message.Context {
+		case "ignore":
+			return fmt.Errorf("%w: other room", ErrIgnore)
+		case "reject":
+			return errors.New("bad signature")
+		}
+		return nil
+	})
+
+	tests := []struct {
+		context string
+		want    pubsub.ValidationResult
+		invalid int
+	}{
+		{"accept", pubsub.ValidationAccept, 0},
+		{"ignore", pubsub.ValidationIgnore, 0},
+		{"reject", pubsub.ValidationReject, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.context, func(t *testing.T) {
+			message, err := NewMessage(tt.context, "payload")
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := message.ToBytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := validator.Validate("default", peer.ID("remote"), &pubsub.Message{Message: &pb.Message{Data: data}})
+			if got != tt.want {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+			if invalid := int(math.Round(validator.invalidCount(peer.ID("remote")))); invalid != tt.invalid {
+				t.Errorf("Validate() invalid count = %d, want %d", invalid, tt.invalid)
+			}
+		})
+	}
+}
+
+func TestMessageValidatorDecay(t *testing.T) {
+	validator := newMessageValidator(peer.ID("local"), nil, nil, nil)
+
+	// invalid messages spread over time never reach blacklist threshold
+	for i := 0; i < 2*maxInvalidMessages; i++ {
+		validator.penalise(peer.ID("remote"))
+		validator.invalid[peer.ID("remote")].updated = time.Now().Add(-invalidHalfLife)
+	}
+	if count := validator.invalidCount(peer.ID("remote")); count >= maxInvalidMessages {
+		t.Errorf("invalidCount() = %f, want decayed below %d", count, maxInvalidMessages)
+	}
+
+	// decayed entries are forgotten
+	validator.invalid[peer.ID("remote")].updated = time.Now().Add(-20 * invalidHalfLife)
+	validator.penalise(peer.ID("other"))
+	if _, ok := validator.invalid[peer.ID("remote")]; ok {
+		t.Error("penalise() decayed peer not removed")
+	}
+}
@@ -0,0 +1,170 @@
+package soroban
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// SeedEnv is the environment variable for hidden service seed
+	SeedEnv = "SOROBAN_SEED"
+	// P2PSeedEnv is the environment variable for p2p seed
+	P2PSeedEnv = "SOROBAN_P2P_SEED"
+	// SeedPassphraseEnv is the environment variable for the passphrase of encrypted seed files
+	SeedPassphraseEnv = "SOROBAN_SEED_PASSPHRASE"
+
+	// encryptedSecretPrefix of secrets encrypted with a passphrase: scrypt:<salt>:<nonce+box> (hex)
+	encryptedSecretPrefix = "scrypt:"
+)
+
+// ChildEnviron return process environment without seed variables, child processes only receive their secrets
+func ChildEnviron() []string {
+	var result []string
+	for _, env := range os.Environ() {
+		name := strings.SplitN(env, "=", 2)[0]
+		if name == SeedEnv || name == P2PSeedEnv || name == SeedPassphraseEnv {
+			continue
+		}
+		result = append(result, env)
+	}
+	return result
+}
+
+// Secrets are sent to child processes over an inherited pipe, not on command line
+type Secrets struct {
+	P2PSeed string `json:",omitempty"`
+}
+
+// LoadSecrets read seeds from files or environment variables.
+// Seed files take precedence over environment variables, which take precedence over options.
+func (p *Options) LoadSecrets() error {
+	passphrase := os.Getenv(SeedPassphraseEnv)
+
+	seed, err := loadSecret(p.Soroban.SeedFile, SeedEnv, passphrase)
+	if err != nil {
+		return fmt.Errorf("seed: %w", err)
+	}
+	if len(seed) > 0 {
+		p.Soroban.Seed = seed
+	}
+
+	p2pSeed, err := loadSecret(p.P2P.SeedFile, P2PSeedEnv, passphrase)
+	if err != nil {
+		return fmt.Errorf("p2p seed: %w", err)
+	}
+	if len(p2pSeed) > 0 {
+		p.P2P.Seed = p2pSeed
+	}
+	return nil
+}
+
+func loadSecret(filename, env, passphrase string) (string, error) {
+	if len(filename) > 0 {
+		return ReadSecretFile(filename, passphrase)
+	}
+	return strings.TrimSpace(os.Getenv(env)), nil
+}
+
+// ReadSecretFile read secret from file, decrypted with passphrase if encrypted
+func ReadSecretFile(filename, passphrase string) (string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(secret, encryptedSecretPrefix) {
+		return secret, nil
+	}
+	if len(passphrase) == 0 {
+		return "", fmt.Errorf("%s is encrypted, %s not set", filename, SeedPassphraseEnv)
+	}
+	return DecryptSecret(secret, passphrase)
+}
+
+func secretKey(passphrase string, salt []byte) (*[32]byte, error) {
+	data, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	var key [32]byte
+	copy(key[:], data)
+	return &key, nil
+}
+
+// EncryptSecret encrypt secret with a key derived from passphrase (scrypt, secretbox)
+func EncryptSecret(secret, passphrase string) (string, error) {
+	if len(passphrase) == 0 {
+		return "", errors.New("empty passphrase")
+	}
+	var salt [16]byte
+	if _, err := io.ReadFull(rand.Reader, salt[:]); err != nil {
+		return "", err
+	}
+	var nonce [24]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return "", err
+	}
+	key, err := secretKey(passphrase, salt[:])
+	if err != nil {
+		return "", err
+	}
+
+	box := secretbox.Seal(nonce[:], []byte(secret), &nonce, key)
+	return encryptedSecretPrefix + hex.EncodeToString(salt[:]) + ":" + hex.EncodeToString(box), nil
+}
+
+// DecryptSecret decrypt secret encrypted by EncryptSecret
+func DecryptSecret(value, passphrase string) (string, error) {
+	parts := strings.Split(strings.TrimPrefix(value, encryptedSecretPrefix), ":")
+	if len(parts) != 2 {
+		return "", errors.New("invalid encrypted secret")
+	}
+	salt, err := hex.DecodeString(parts[0])
+	if err != nil {
+		return "", err
+	}
+	box, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return "", err
+	}
+	if len(box) < 24 {
+		return "", errors.New("invalid encrypted secret")
+	}
+	key, err := secretKey(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+
+	var nonce [24]byte
+	copy(nonce[:], box[:24])
+	secret, ok := secretbox.Open(nil, box[24:], &nonce, key)
+	if !ok {
+		return "", errors.New("invalid passphrase")
+	}
+	return string(secret), nil
+}
+
+// ReadSecrets read secrets sent by parent process on file descriptor
+func ReadSecrets(fd int) (Secrets, error) {
+	file := os.NewFile(uintptr(fd), "secrets")
+	if file == nil {
+		return Secrets{}, errors.New("invalid secrets file descriptor")
+	}
+	defer file.Close()
+
+	var result Secrets
+	err := json.NewDecoder(io.LimitReader(file, 64*1024)).Decode(&result)
+	if err != nil {
+		return Secrets{}, err
+	}
+	return result, nil
+}
@@ -0,0 +1,78 @@
+package soroban
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptSecret(t *testing.T) {
+	seed := "c2d0b9870b89b10a47aa7e33fd3b51dc86eaa160d764e3b16ad3924356cc84d9"
+
+	encrypted, err := EncryptSecret(seed, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := DecryptSecret(encrypted, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted != seed {
+		t.Errorf("DecryptSecret() = %s, want %s", decrypted, seed)
+	}
+	if _, err := DecryptSecret(encrypted, "wrong"); err == nil {
+		t.Error("DecryptSecret() expected error with wrong passphrase")
+	}
+
+	filename := filepath.Join(t.TempDir(), "seed")
+	if err := os.WriteFile(filename, []byte(encrypted+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadSecretFile(filename, ""); err == nil {
+		t.Error("ReadSecretFile() expected error without passphrase")
+	}
+	if value, err := ReadSecretFile(filename, "passphrase"); err != nil || value != seed {
+		t.Errorf("ReadSecretFile() = %s, %v", value, err)
+	}
+}
+
+func TestLoadSecrets(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "p2p.seed")
+	if err := os.WriteFile(filename, []byte("file-seed\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(SeedEnv, "env-seed")
+	t.Setenv(P2PSeedEnv, "env-p2p-seed")
+
+	options := DefaultOptions
+	options.Soroban.Seed = "flag-seed"
+	options.P2P.SeedFile = filename
+	if err := options.LoadSecrets(); err != nil {
+		t.Fatal(err)
+	}
+	if options.Soroban.Seed != "env-seed" {
+		t.Errorf("Soroban.Seed = %s, want env-seed", options.Soroban.Seed)
+	}
+	if options.P2P.Seed != "file-seed" {
+		t.Errorf("P2P.Seed = %s, want file-seed", options.P2P.Seed)
+	}
+}
+
+func TestChildEnviron(t *testing.T) {
+	t.Setenv(SeedEnv, "seed")
+	t.Setenv(P2PSeedEnv, "p2p-seed")
+	t.Setenv(SeedPassphraseEnv, "passphrase")
+	t.Setenv("SOROBAN_TEST", "value")
+
+	found := false
+	for _, env := range ChildEnviron() {
+		if strings.HasPrefix(env, "SOROBAN_SEED") || strings.HasPrefix(env, P2PSeedEnv) {
+			t.Errorf("ChildEnviron() contains %s", env)
+		}
+		found = found || env == "SOROBAN_TEST=value"
+	}
+	if !found {
+		t.Error("ChildEnviron() missing SOROBAN_TEST")
+	}
+}
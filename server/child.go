@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
@@ -10,6 +11,7 @@ import (
 
 	soroban "code.samourai.io/wallet/samourai-soroban"
 	"code.samourai.io/wallet/samourai-soroban/ipc"
+	"code.samourai.io/wallet/samourai-soroban/p2p"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -28,22 +30,118 @@ func startChildSoroban(ctx context.Context, options soroban.Options, childID int
 		log.Fatal("Soroban executable not found")
 	}
 
-	go ipc.StartProcessDaemon(ctx, fmt.Sprintf("soroban-child-%d", childID),
-		executablePath,
+	// each child has its own identity, derived from master seed or in its own key file
+	p2pSeed, err := p2p.DeriveChildSeed(options.P2P.Seed, childID)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to derive child p2p seed")
+	}
+	var p2pKeyFile string
+	if len(options.P2P.KeyFile) > 0 {
+		p2pKeyFile = fmt.Sprintf("%s.%d", options.P2P.KeyFile, childID)
+	}
+	logChildAddr(p2pSeed, p2pKeyFile, options.P2P.ListenPort+childID, childID)
+
+	args := []string{
 		// "--config", optionsc.Soroban.Config,
 		"--ipcChildID", strconv.Itoa(childID),
+		"--confidential", options.Soroban.Confidential,
+		"--confidentialAdmin", options.Soroban.ConfidentialAdmin,
+		"--confidentialAdminAlgorithm", options.Soroban.ConfidentialAdminAlgorithm,
+		"--confidentialRuleset", options.Soroban.ConfidentialRuleset,
 		"--ipcNatsHost", options.IPC.NatsHost,
 		"--ipcNatsPort", strconv.Itoa(options.IPC.NatsPort),
-		"--p2pSeed", options.P2P.Seed,
-		"--p2pBootstrap", options.P2P.Bootstrap,
+		"--p2pBootstrap", options.P2P.Bootstrap.String(),
 		"--p2pRoom", options.P2P.Room,
 		"--p2pHostname", options.P2P.Hostname,
-		"--p2pListenPort", strconv.Itoa(options.P2P.ListenPort+childID),
+		"--p2pListenPort", strconv.Itoa(options.P2P.ListenPort + childID),
 		"--log", log.GetLevel().String(),
+	}
+	if len(options.P2P.PeerBook) > 0 {
+		// one peer book per child
+		args = append(args, "--p2pPeerBook", fmt.Sprintf("%s.%d", options.P2P.PeerBook, childID))
+	}
+	if len(p2pKeyFile) > 0 {
+		args = append(args, "--p2pKeyFile", p2pKeyFile)
+	}
+	if options.P2P.ExitTimeout > 0 {
+		args = append(args, "--p2pExitTimeout", strconv.Itoa(options.P2P.ExitTimeout))
+	}
+	// enabled by default, always passed to allow disabling it
+	args = append(args, fmt.Sprintf("--p2pLegacyHeartbeat=%t", options.P2P.LegacyHeartbeat))
+	if len(options.P2P.Security) > 0 {
+		args = append(args, "--p2pSecurity", options.P2P.Security)
+	}
+	if options.P2P.MinPeers > 0 {
+		args = append(args, "--p2pMinPeers", strconv.Itoa(options.P2P.MinPeers))
+	}
+	if len(options.P2P.Encoding) > 0 {
+		args = append(args, "--p2pEncoding", options.P2P.Encoding)
+	}
+	if len(options.P2P.BanList) > 0 {
+		args = append(args, "--p2pBanList", options.P2P.BanList)
+	}
+	score, err := json.Marshal(options.P2P.Score)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to marshal p2p score")
+	}
+	args = append(args, "--p2pScore", string(score))
+	queue, err := json.Marshal(options.P2P.Queue)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to marshal p2p queue")
+	}
+	args = append(args, "--p2pQueue", string(queue))
+	if len(options.P2P.Replication) > 0 {
+		replication, err := json.Marshal(options.P2P.Replication)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to marshal p2p replication")
+		}
+		args = append(args, "--p2pReplication", string(replication))
+	}
+	if len(options.P2P.Rooms) > 0 {
+		rooms, err := json.Marshal(options.P2P.Rooms)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to marshal p2p rooms")
+		}
+		args = append(args, "--p2pRooms", string(rooms))
+	}
+
+	// secrets are sent over an inherited pipe
+	var secrets []byte
+	if len(p2pSeed) > 0 {
+		secrets, err = json.Marshal(soroban.Secrets{P2PSeed: p2pSeed})
+		if err != nil {
+			log.WithError(err).Fatal("Failed to marshal child secrets")
+		}
+		args = append(args, "--secretsFd", strconv.Itoa(ipc.SecretsFd))
+	}
+
+	go ipc.StartProcessDaemon(ctx, fmt.Sprintf("soroban-child-%d", childID),
+		executablePath,
+		secrets,
+		soroban.ChildEnviron(),
+		args...,
 	)
 
 }
 
+// logChildAddr report p2p address of child, unless identity is ephemeral
+func logChildAddr(p2pSeed, p2pKeyFile string, listenPort, childID int) {
+	if len(p2pKeyFile) == 0 && (len(p2pSeed) == 0 || p2pSeed == "auto") {
+		return
+	}
+	priv, err := p2p.LoadIdentity(p2pSeed, p2pKeyFile)
+	if err != nil {
+		log.WithError(err).WithField("ChildID", childID).Error("Failed to load child p2p identity")
+		return
+	}
+	addr, err := p2p.IdentityAddr(priv, listenPort)
+	if err != nil {
+		log.WithError(err).WithField("ChildID", childID).Error("Failed to get child p2p address")
+		return
+	}
+	log.WithField("ChildID", childID).WithField("Addr", addr.String()).Info("P2P child addr")
+}
+
 func fileExists(filename string) bool {
 	_, err := os.Stat(filename)
 	if os.IsNotExist(err) {
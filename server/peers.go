@@ -0,0 +1,20 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"code.samourai.io/wallet/samourai-soroban/services"
+)
+
+// PeersHandler return connected peers and topology of p2p nodes
+func PeersHandler(w http.ResponseWriter, r *http.Request) {
+	jsonResponse, err := json.Marshal(services.P2PPeers(r.Context()))
+	if err != nil {
+		http.Error(w, "Error creating JSON response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonResponse)
+}
@@ -16,6 +16,7 @@ import (
 	soroban "code.samourai.io/wallet/samourai-soroban"
 	"code.samourai.io/wallet/samourai-soroban/confidential"
 	"code.samourai.io/wallet/samourai-soroban/internal"
+	"code.samourai.io/wallet/samourai-soroban/internal/ratelimit"
 	"code.samourai.io/wallet/samourai-soroban/ipc"
 	"code.samourai.io/wallet/samourai-soroban/p2p"
 	"code.samourai.io/wallet/samourai-soroban/services"
@@ -32,6 +33,8 @@ type Soroban struct {
 	p2p       *p2p.P2P
 	ipc       *ipc.IPCService
 	directory soroban.Directory
+	domain    string
+	limiter   *ratelimit.Limiter
 	t         *tor.Tor
 	onion     *tor.OnionService
 	started   chan bool
@@ -41,6 +44,18 @@ type Soroban struct {
 func New(ctx context.Context, options soroban.Options) (context.Context, *Soroban) {
 	var directory soroban.Directory
 
+	if len(options.Soroban.ConfidentialAdmin) > 0 {
+		// children only read ruleset file, adopted rulesets are saved by IPC server
+		rulesetFile := options.Soroban.ConfidentialRuleset
+		if options.IPC.ChildID > 0 {
+			rulesetFile = ""
+		}
+		err := confidential.SetAdmin(options.Soroban.ConfidentialAdminAlgorithm, options.Soroban.ConfidentialAdmin, rulesetFile)
+		if err != nil {
+			log.WithError(err).Fatal("Invalid confidential admin key")
+		}
+		go confidential.RulesetWatcher(ctx, options.Soroban.ConfidentialRuleset)
+	}
 	if len(options.Soroban.Confidential) > 0 {
 		go confidential.ConfigWatcher(ctx, options.Soroban.Confidential)
 	}
@@ -57,7 +72,7 @@ func New(ctx context.Context, options soroban.Options) (context.Context, *Soroba
 
 	startIPCService := options.IPC.ChildProcessCount > 0 && options.IPC.ChildID == 0
 	startMainSoroban := startIPCService || (options.IPC.ChildProcessCount == 0 && options.IPC.ChildID == 0)
-	startP2PDirectory := len(options.P2P.Bootstrap) > 0 && (options.IPC.ChildProcessCount == 0 || options.IPC.ChildID > 0)
+	startP2PDirectory := (options.P2P.HasBootstrap() || hasKnownPeers(options.P2P.PeerBook)) && (options.IPC.ChildProcessCount == 0 || options.IPC.ChildID > 0)
 
 	ipcMode := "peer"
 	if !startMainSoroban {
@@ -65,7 +80,45 @@ func New(ctx context.Context, options soroban.Options) (context.Context, *Soroba
 	}
 
 	ctx = context.WithValue(ctx, internal.SorobanDirectoryKey, directory)
-	ctx = context.WithValue(ctx, internal.SorobanP2PKey, &p2p.P2P{OnMessage: make(chan p2p.Message)})
+	ctx = context.WithValue(ctx, internal.SorobanDomainKey, options.Soroban.Domain)
+	if startMainSoroban {
+		// rate limits apply to json-rpc requests, served by the main process only
+		limiter := ratelimit.New()
+		go limiter.Start(ctx)
+		ctx = context.WithValue(ctx, internal.SorobanRateLimitKey, limiter)
+	}
+	p2P := &p2p.P2P{OnMessage: make(chan p2p.Message)}
+	// ban list is shared with children through file
+	banList, err := p2p.LoadBanList(options.P2P.BanList)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to load ban list")
+	}
+	p2P.SetBanList(banList)
+	p2P.SetScore(options.P2P.Score)
+	replication := p2pReplication(options.P2P)
+	if err := p2p.ValidateReplication(p2pRooms(options.P2P), replication); err != nil {
+		log.WithError(err).Fatal("Invalid p2p replication")
+	}
+	p2P.SetReplication(replication)
+	queue, err := p2p.NewMessageQueue(options.P2P.Queue)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid p2p queue")
+	}
+	p2P.SetQueue(queue)
+	p2P.SetKeyFile(options.P2P.KeyFile)
+	if len(options.P2P.Security) > 0 {
+		security, err := p2p.ParseSecurity(options.P2P.Security)
+		if err != nil {
+			log.WithError(err).Fatal("Invalid p2p security")
+		}
+		p2P.SetSecurity(security)
+	}
+	encoding, err := p2p.ParseEncoding(options.P2P.Encoding)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid p2p encoding")
+	}
+	p2P.SetEncoding(encoding)
+	ctx = context.WithValue(ctx, internal.SorobanP2PKey, p2P)
 	if options.IPC.ChildProcessCount > 0 || options.IPC.ChildID > 0 {
 		ctx = context.WithValue(ctx, internal.SorobanIPCKey, ipc.New(ctx, ipc.IPCOptions{
 			Mode:     ipcMode,
@@ -142,7 +195,17 @@ func New(ctx context.Context, options soroban.Options) (context.Context, *Soroba
 
 						log.WithField("p2pMessage", fmt.Sprintf("%s: %s", p2pMessage.Context, string(p2pMessage.Payload))).Debug("Publish Message to p2p")
 
-						err = p2P.PublishJson(ctx, p2pMessage.Context, &args)
+						// local keys never leave the node
+						if !p2P.Replicated(args.Name) {
+							err = errors.New("key not replicated")
+						}
+						var forwarded p2p.Message
+						if err == nil {
+							forwarded, err = p2pMessage.Forward()
+						}
+						if err == nil {
+							err = p2P.PublishMessage(ctx, p2P.RoomFor(args.Name), forwarded)
+						}
 						if err != nil {
 							log.WithError(err).Error("Failed to Publish P2P message")
 							return ipc.Message{
@@ -154,6 +217,17 @@ func New(ctx context.Context, options soroban.Options) (context.Context, *Soroba
 							Type:    message.Type,
 							Message: "success",
 						}, nil
+					case ipc.MessageTypeLookup:
+						response, err := services.LookupHandler(ctx, message)
+						if err != nil {
+							log.WithError(err).Debug("Failed to lookup from peers")
+							return ipc.Message{
+								Type:    message.Type,
+								Message: "error",
+							}, nil
+						}
+						return response, nil
+
 					default:
 						return ipc.Message{
 							Type:    message.Type,
@@ -168,7 +242,7 @@ func New(ctx context.Context, options soroban.Options) (context.Context, *Soroba
 		}
 
 		ready := make(chan struct{})
-		go services.StartP2PDirectory(ctx, options.P2P.Seed, options.P2P.Bootstrap, options.P2P.Hostname, options.P2P.ListenPort, options.P2P.Room, ready)
+		go services.StartP2PDirectory(ctx, options.P2P.Seed, options.P2P.Hostname, options.P2P.ListenPort, p2pRooms(options.P2P), options.P2P.PeerBook, time.Duration(options.P2P.ExitTimeout)*time.Minute, options.P2P.LegacyHeartbeat, ready)
 		<-ready
 		log.Info("P2PDirectory service started")
 	}
@@ -211,9 +285,54 @@ func New(ctx context.Context, options soroban.Options) (context.Context, *Soroba
 		started:   make(chan bool),
 		rpcServer: rpcServer,
 		directory: directory,
+		domain:    options.Soroban.Domain,
+		limiter:   internal.RateLimiterFromContext(ctx),
 	}
 }
 
+// hasKnownPeers return true if peer book knows peers, p2p can start without bootstrap
+func hasKnownPeers(filename string) bool {
+	if len(filename) == 0 {
+		return false
+	}
+	book, err := p2p.LoadPeerBook(filename)
+	if err != nil {
+		return false
+	}
+	return book.Len() > 0
+}
+
+// p2pRooms return default room followed by configured rooms
+func p2pRooms(options soroban.P2PInfo) []p2p.Room {
+	rooms := []p2p.Room{
+		{
+			Name:      options.Room,
+			Bootstrap: options.Bootstrap,
+			MinPeers:  options.MinPeers,
+		},
+	}
+	for _, room := range options.Rooms {
+		rooms = append(rooms, p2p.Room{
+			Name:      room.Name,
+			Prefixes:  room.Prefixes,
+			Bootstrap: room.Bootstrap,
+			MinPeers:  room.MinPeers,
+		})
+	}
+	return rooms
+}
+
+func p2pReplication(options soroban.P2PInfo) []p2p.Replication {
+	var result []p2p.Replication
+	for _, entry := range options.Replication {
+		result = append(result, p2p.Replication{
+			Prefix: entry.Prefix,
+			Policy: entry.Policy,
+		})
+	}
+	return result
+}
+
 /// Soroban interface
 
 func (p *Soroban) ID() string {
@@ -284,6 +403,7 @@ func (p *Soroban) startServer(hostname string, port int, listener net.Listener)
 	router.HandleFunc("/rpc", rpcHandler)
 	router.HandleFunc("/stats", stats.StatsHandler)
 	router.HandleFunc("/status", StatusHandler)
+	router.HandleFunc("/peers", PeersHandler)
 
 	mainHandler := c.Handler(router)
 
@@ -333,6 +453,10 @@ func (p *Soroban) createHttpServer(addr string, handler http.Handler, listenerTy
 
 		ConnContext: func(ctx context.Context, c net.Conn) context.Context {
 			ctx = context.WithValue(ctx, internal.SorobanDirectoryKey, p.directory)
+			ctx = context.WithValue(ctx, internal.SorobanDomainKey, p.domain)
+			if p.limiter != nil {
+				ctx = context.WithValue(ctx, internal.SorobanRateLimitKey, p.limiter)
+			}
 			if p.p2p != nil {
 				ctx = context.WithValue(ctx, internal.SorobanP2PKey, p.p2p)
 			}
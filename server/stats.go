@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"code.samourai.io/wallet/samourai-soroban/services"
 )
 
 type ContextKey string
@@ -140,6 +142,7 @@ func (s *Stats) StatsHandler(w http.ResponseWriter, r *http.Request) {
 		"last_24h": ipv4["last_24h"] + tor["last_24h"],
 		"ipv4":     ipv4,
 		"tor":      tor,
+		"p2p":      services.P2PStatus(r.Context()),
 	}
 
 	jsonResponse, err := json.Marshal(response)
@@ -1,13 +1,17 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	soroban "code.samourai.io/wallet/samourai-soroban"
 	"code.samourai.io/wallet/samourai-soroban/internal"
+	"code.samourai.io/wallet/samourai-soroban/p2p"
+	"code.samourai.io/wallet/samourai-soroban/services"
 )
 
 func StatusHandler(w http.ResponseWriter, r *http.Request) {
@@ -25,7 +29,9 @@ func StatusHandler(w http.ResponseWriter, r *http.Request) {
 		Keyspace: fullStatus.Keyspace,
 		Memory:   fullStatus.Memory,
 		Stats:    fullStatus.Stats,
+		P2P:      p2pState(r.Context()),
 	}
+	fullStatus.P2P = status.P2P
 
 	// filter informations
 	filtersQuery := r.URL.Query().Get("filters")
@@ -46,6 +52,7 @@ func StatusHandler(w http.ResponseWriter, r *http.Request) {
 				CPU:      status.CPU,
 				Clients:  status.Clients,
 				Keyspace: status.Keyspace,
+				P2P:      status.P2P,
 			}
 
 		case "cpu":
@@ -59,6 +66,8 @@ func StatusHandler(w http.ResponseWriter, r *http.Request) {
 			result.Memory = status.Memory
 		case "stats":
 			result.Stats = status.Stats
+		case "p2p":
+			result.P2P = status.P2P
 
 		case "*":
 			result = status
@@ -79,3 +88,33 @@ func StatusHandler(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Fprint(w, string(data))
 }
+
+// p2pState return state of p2p nodes, `degraded` if any node is not connected
+func p2pState(ctx context.Context) soroban.NameValue {
+	nodes := services.P2PStatus(ctx)
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	result := soroban.NameValue{
+		"state": string(p2p.StateConnected),
+		"nodes": strconv.Itoa(len(nodes)),
+	}
+	degraded := 0
+	for _, node := range nodes {
+		if node.State == p2p.StateDegraded || node.State == p2p.StateRecovering {
+			degraded++
+		}
+		result[node.PeerID] = string(node.State)
+		if node.Health != nil {
+			result[node.PeerID+".peers"] = strconv.Itoa(node.Health.Peers)
+			result[node.PeerID+".rtt"] = node.Health.MedianRTT
+			result[node.PeerID+".clock_offset"] = node.Health.MaxClockOffset
+		}
+	}
+	if degraded > 0 {
+		result["state"] = string(p2p.StateDegraded)
+	}
+	result["degraded"] = strconv.Itoa(degraded)
+	return result
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"code.samourai.io/wallet/samourai-soroban/confidential"
+	"code.samourai.io/wallet/samourai-soroban/internal"
+	"code.samourai.io/wallet/samourai-soroban/p2p"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// AdminBanListName is the name signed to read the ban list
+	AdminBanListName = "p2p.banlist"
+)
+
+// P2P struct for json-rpc
+type P2P struct{}
+
+// BanListResponse for json-rpc response
+type BanListResponse struct {
+	Entries []p2p.BanEntry
+}
+
+// adminInfo return confidential entry for admin key
+func adminInfo() (confidential.ConfidentialEntry, error) {
+	algorithm, publicKey := confidential.Admin()
+	if len(publicKey) == 0 {
+		return confidential.ConfidentialEntry{}, errors.New("admin disabled")
+	}
+	return confidential.ConfidentialEntry{
+		Prefix:    "p2p.*",
+		Algorithm: algorithm,
+		PublicKey: publicKey,
+		ReadOnly:  true,
+	}, nil
+}
+
+// verifyAdmin check admin request is signed by admin key with v2 message
+func verifyAdmin(ctx context.Context, args *DirectoryEntry, operation string) error {
+	info, err := adminInfo()
+	if err != nil {
+		return err
+	}
+	if confidential.SignatureVersion(args.Version) != confidential.SignatureV2 || len(args.Tokens) > 0 {
+		return errors.New("admin request must be signed with v2 message")
+	}
+	err = args.VerifySignature(info, internal.DomainFromContext(ctx), operation)
+	if err != nil {
+		return err
+	}
+	return checkReplay(args.Version, args.PublicKey, args.Nonce)
+}
+
+func banList(ctx context.Context) (*p2p.BanList, error) {
+	p2P := internal.P2PFromContext(ctx)
+	if p2P == nil || p2P.BanList() == nil {
+		return nil, errors.New("ban list not found")
+	}
+	return p2P.BanList(), nil
+}
+
+// Ban peer, Name is the peer ID and Entry the reason
+func (t *P2P) Ban(r *http.Request, args *DirectoryEntry, result *Response) error {
+	ctx := r.Context()
+	err := verifyAdmin(ctx, args, confidential.OperationBan)
+	if err != nil {
+		log.WithError(err).Error("Failed to verify admin request")
+		return err
+	}
+	list, err := banList(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = list.Ban(args.Name, args.Entry)
+	if err != nil {
+		log.WithError(err).WithField("PeerID", args.Name).Error("Failed to ban peer")
+		*result = Response{
+			Status: "error",
+		}
+		return nil
+	}
+	log.WithField("PeerID", args.Name).WithField("Reason", args.Entry).Info("Peer banned")
+
+	*result = Response{
+		Status: "success",
+	}
+	return nil
+}
+
+// Unban peer, Name is the peer ID
+func (t *P2P) Unban(r *http.Request, args *DirectoryEntry, result *Response) error {
+	ctx := r.Context()
+	err := verifyAdmin(ctx, args, confidential.OperationUnban)
+	if err != nil {
+		log.WithError(err).Error("Failed to verify admin request")
+		return err
+	}
+	list, err := banList(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = list.Unban(args.Name)
+	if err != nil {
+		log.WithError(err).WithField("PeerID", args.Name).Error("Failed to unban peer")
+		*result = Response{
+			Status: "error",
+		}
+		return nil
+	}
+	log.WithField("PeerID", args.Name).Info("Peer unbanned")
+
+	*result = Response{
+		Status: "success",
+	}
+	return nil
+}
+
+// BanList return banned peers, request is a list of `p2p.banlist` signed by admin key
+func (t *P2P) BanList(r *http.Request, args *DirectoryEntries, result *BanListResponse) error {
+	ctx := r.Context()
+	info, err := adminInfo()
+	if err != nil {
+		return err
+	}
+	if args.Name != AdminBanListName || confidential.SignatureVersion(args.Version) != confidential.SignatureV2 {
+		return errors.New("invalid admin request")
+	}
+	err = args.VerifySignature(info, internal.DomainFromContext(ctx))
+	if err == nil {
+		err = checkReplay(args.Version, args.PublicKey, args.Nonce)
+	}
+	if err != nil {
+		log.WithError(err).Error("Failed to verify admin request")
+		return err
+	}
+	list, err := banList(ctx)
+	if err != nil {
+		return err
+	}
+
+	*result = BanListResponse{
+		Entries: list.Entries(),
+	}
+	return nil
+}
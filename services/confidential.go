@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+
+	"code.samourai.io/wallet/samourai-soroban/confidential"
+	"code.samourai.io/wallet/samourai-soroban/ipc"
+	"code.samourai.io/wallet/samourai-soroban/p2p"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ContextConfidentialRuleset = "Confidential.Ruleset"
+)
+
+// applyRuleset adopt admin ruleset received from p2p or IPC.
+// Ruleset is saved to ruleset file only by IPC server, or in single process mode.
+func applyRuleset(ruleset confidential.Ruleset) error {
+	if !confidential.AdminEnabled() {
+		log.Debug("Admin ruleset disabled, skipping ruleset")
+		return nil
+	}
+
+	adopted, err := confidential.AdoptRuleset(ruleset)
+	if err != nil {
+		log.WithError(err).WithField("Version", ruleset.Version).Warning("Admin ruleset rejected")
+		return err
+	}
+	if adopted {
+		log.WithField("Version", ruleset.Version).Info("Admin ruleset received from p2p")
+	}
+	return nil
+}
+
+// processRuleset apply admin ruleset received from p2p, and forward it to IPC server in child mode
+func processRuleset(client *ipc.IPCService, sorobanMode string, message p2p.Message) {
+	var ruleset confidential.Ruleset
+	err := message.ParsePayload(&ruleset)
+	if err != nil {
+		log.WithError(err).Error("Failed to parse admin ruleset")
+		return
+	}
+	err = applyRuleset(ruleset)
+	if err != nil || sorobanMode != "child" {
+		return
+	}
+
+	data, err := json.Marshal(&ruleset)
+	if err != nil {
+		log.WithError(err).Error("Failed to marshal admin ruleset")
+		return
+	}
+	response, err := client.Request(ipc.Message{
+		Type:    ipc.MessageTypeRuleset,
+		Payload: string(data),
+	}, "up")
+	if err != nil {
+		log.WithError(err).Error("Failed to forward admin ruleset")
+		return
+	}
+	if response.Message != "success" {
+		log.WithField("Message", response.Message).Warning("IPC ruleset message failed")
+	}
+}
+
+// rulesetHandler adopt admin ruleset forwarded by IPC children
+func rulesetHandler(message ipc.Message) (ipc.Message, error) {
+	var ruleset confidential.Ruleset
+	err := json.Unmarshal([]byte(message.Payload), &ruleset)
+	if err != nil {
+		return ipc.Message{}, err
+	}
+	err = applyRuleset(ruleset)
+	if err != nil {
+		return ipc.Message{}, err
+	}
+	return ipc.Message{
+		Type:    message.Type,
+		Message: "success",
+	}, nil
+}
+
+// publishRuleset republish adopted admin ruleset to peers of every room
+func publishRuleset(ctx context.Context, p2P *p2p.P2P) {
+	ruleset, ok := confidential.CurrentRuleset()
+	if !ok {
+		return
+	}
+	message, err := p2p.NewMessage(ContextConfidentialRuleset, &ruleset)
+	if err != nil {
+		log.WithError(err).Warning("Failed to create admin ruleset message")
+		return
+	}
+	for _, room := range p2P.Rooms() {
+		err := p2P.PublishMessage(ctx, room, message)
+		if err != nil {
+			log.WithError(err).WithField("Room", room).Warning("Failed to publish admin ruleset")
+			continue
+		}
+		log.WithField("Version", ruleset.Version).WithField("Room", room).Trace("Admin ruleset published")
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -21,10 +22,13 @@ import (
 type DirectoryEntries struct {
 	Name      string
 	Limit     int
+	Federated bool `json:",omitempty"`
 	PublicKey string
 	Algorithm string
 	Signature string
 	Timestamp int64
+	Version   int    `json:",omitempty"`
+	Nonce     string `json:",omitempty"`
 }
 
 // DirectoryEntriesResponse for json-rpc response
@@ -42,6 +46,9 @@ type DirectoryEntry struct {
 	Algorithm string
 	Signature string
 	Timestamp int64
+	Version   int                  `json:",omitempty"`
+	Nonce     string               `json:",omitempty"`
+	Tokens    []confidential.Token `json:",omitempty"`
 }
 
 // Directory struct for json-rpc
@@ -57,7 +64,10 @@ func (t *Directory) List(r *http.Request, args *DirectoryEntries, result *Direct
 	info := confidential.GetConfidentialInfo(args.Name, args.PublicKey)
 	// check signature if key is confidential, list is not allowed for anonymous
 	if info.Confidential {
-		err := args.VerifySignature(info)
+		err := args.VerifySignature(info, internal.DomainFromContext(r.Context()))
+		if err == nil {
+			err = checkReplay(args.Version, args.PublicKey, args.Nonce)
+		}
 		if err != nil {
 			log.WithError(err).Error("Failed to verifySignature")
 			return nil
@@ -70,6 +80,16 @@ func (t *Directory) List(r *http.Request, args *DirectoryEntries, result *Direct
 		return nil
 	}
 
+	// ask peers only when requested by client, lookups can take up to a few seconds
+	if args.Federated {
+		federated, err := federatedLookup(r.Context(), directory, args)
+		if err != nil {
+			log.WithError(err).WithField("Name", args.Name).Debug("Federated lookup failed")
+		} else {
+			entries = federated
+		}
+	}
+
 	if args.Limit > 0 && args.Limit < len(entries) {
 		rand.Shuffle(len(entries), func(i, j int) {
 			entries[i], entries[j] = entries[j], entries[i]
@@ -104,10 +124,13 @@ func (t *Directory) Add(r *http.Request, args *DirectoryEntry, result *Response)
 		return nil
 	}
 
-	info := confidential.GetConfidentialInfo(args.Name, args.PublicKey)
+	info := confidential.GetConfidentialInfo(args.Name, args.RulePublicKey())
 	// check signature if key is readonly, add is not allowed for anonymous
 	if info.ReadOnly {
-		err := args.VerifySignature(info)
+		err := args.VerifySignature(info, internal.DomainFromContext(ctx), confidential.OperationAdd)
+		if err == nil {
+			err = checkReplay(args.Version, args.PublicKey, args.Nonce)
+		}
 		if err != nil {
 			log.WithError(err).Error("Failed to verifySignature")
 			*result = Response{
@@ -117,6 +140,11 @@ func (t *Directory) Add(r *http.Request, args *DirectoryEntry, result *Response)
 		}
 	}
 
+	// only client requests are limited, not writes replicated from peers
+	if err := checkRateLimit(ctx, args, info.ReadOnly); err != nil {
+		return err
+	}
+
 	log.Debugf("Add: %s %s", args.Name, args.Entry)
 
 	err := addToDirectory(directory, args)
@@ -128,61 +156,63 @@ func (t *Directory) Add(r *http.Request, args *DirectoryEntry, result *Response)
 		return nil
 	}
 
-	if client := internal.IPCFromContext(ctx); client != nil {
-		log.Debug("Forward Message message to IPC client")
-		message, err := p2p.NewMessage("Directory.Add", &args)
-		if err != nil {
-			log.WithError(err).Error("failed to marshal p2P message.")
-			*result = Response{
-				Status: "error",
-			}
-			return nil
+	err = publishEntry(ctx, "Directory.Add", args)
+	if err != nil {
+		log.WithError(err).Error("Failed to publish entry")
+		*result = Response{
+			Status: "error",
 		}
+		return nil
+	}
+
+	*result = Response{
+		Status: "success",
+	}
+	return nil
+}
+
+// publishEntry forward entry to IPC children and publish it to p2p, with the same message ID
+func publishEntry(ctx context.Context, context string, args *DirectoryEntry) error {
+	// local keys never leave the node
+	p2P := internal.P2PFromContext(ctx)
+	if p2P != nil && !p2P.Replicated(args.Name) {
+		return nil
+	}
 
+	message, err := p2p.NewMessage(context, args)
+	if err != nil {
+		return err
+	}
+	// message relayed back by children or peers must not be applied again
+	seenMessages.Seen(message.Hash())
+
+	if client := internal.IPCFromContext(ctx); client != nil {
+		log.Debug("Forward Message message to IPC client")
 		data, err := json.Marshal(message)
 		if err != nil {
-			log.WithError(err).Error("failed to marshal p2p message")
-			*result = Response{
-				Status: "error",
-			}
-			return nil
+			return err
 		}
 		resp, err := client.Request(ipc.Message{
 			Type:    ipc.MessageTypeIPC,
 			Payload: string(data),
 		}, "down")
 		if err != nil {
-			log.WithError(err).Error("IPC requext failed")
-			*result = Response{
-				Status: "error",
-			}
-			return nil
+			return err
 		}
 		if resp.Message != "success" {
 			log.WithField("Message", resp.Message).Warning("IPC Message failed")
+			return fmt.Errorf("child failed to publish: %s", resp.Message)
 		}
 		log.WithField("Message", resp.Message).Debug("IPC Message sent")
-
-	} else {
-		log.Warning("IPC Client not found in context")
 	}
 
-	if p2P := internal.P2PFromContext(ctx); p2P != nil {
-
-		err := p2P.PublishJson(ctx, "Directory.Add", args)
-		if err != nil {
-			// non fatal error
-			log.Printf("p2P - Failed to PublishJson. %s\n", err)
+	if p2P != nil {
+		err := p2P.PublishMessage(ctx, p2P.RoomFor(args.Name), message)
+		// p2p is not started by IPC server, children publish
+		if err != nil && err != p2p.ErrNotStarted {
+			return err
 		}
-
-		*result = Response{
-			Status: "success",
-		}
-	} else {
-		log.Println("p2P - P2P not found")
-		return nil
 	}
-
 	return nil
 }
 
@@ -201,20 +231,21 @@ func (t *Directory) Remove(r *http.Request, args *DirectoryEntry, result *Respon
 		return nil
 	}
 
-	info := confidential.GetConfidentialInfo(args.Name, args.PublicKey)
+	info := confidential.GetConfidentialInfo(args.Name, args.RulePublicKey())
 	// check signature if key is readonly, remove is not allowed for anonymous
 	if info.ReadOnly {
-		err := args.VerifySignature(info)
+		err := args.VerifySignature(info, internal.DomainFromContext(ctx), confidential.OperationRemove)
+		if err == nil {
+			err = checkReplay(args.Version, args.PublicKey, args.Nonce)
+		}
 		if err != nil {
 			log.WithError(err).Error("Failed to verifySignature")
 			return nil
 		}
 	}
 
-	p2P := internal.P2PFromContext(ctx)
-	if p2P == nil {
-		log.Println("p2P - P2P not found")
-		return nil
+	if err := checkRateLimit(ctx, args, info.ReadOnly); err != nil {
+		return err
 	}
 
 	log.Debugf("Remove: %s %s", args.Name, args.Entry)
@@ -226,10 +257,10 @@ func (t *Directory) Remove(r *http.Request, args *DirectoryEntry, result *Respon
 		log.WithError(err).Error("Failed to Remove directory")
 	}
 
-	err = p2P.PublishJson(ctx, "Directory.Remove", args)
+	err = publishEntry(ctx, "Directory.Remove", args)
 	if err != nil {
-		// non fatal error
-		log.Printf("p2P - Failed to PublishJson. %s\n", err)
+		status = "error"
+		log.WithError(err).Error("Failed to publish entry")
 	}
 
 	*result = Response{
@@ -238,47 +269,98 @@ func (t *Directory) Remove(r *http.Request, args *DirectoryEntry, result *Respon
 	return nil
 }
 
+// checkReplay reject v2 requests already received, must only be called for requests received from clients.
+// Gossip and IPC replicate the same signed request to every node.
+func checkReplay(version int, publicKey, nonce string) error {
+	if confidential.SignatureVersion(version) != confidential.SignatureV2 {
+		return nil
+	}
+	return confidential.CheckNonce(publicKey, nonce)
+}
+
 func timeInRange(start, end, check time.Time) bool {
 	return check.After(start) && check.Before(end)
 }
 
-func (p *DirectoryEntries) VerifySignature(info confidential.ConfidentialEntry) error {
+// SignatureMessage return the message signed by client for list
+func (p *DirectoryEntries) SignatureMessage(domain string) (string, error) {
+	return confidential.SignatureMessage(p.Version, confidential.OperationList, domain, p.Name, "", "", p.Timestamp, p.Nonce)
+}
+
+func (p *DirectoryEntries) VerifySignature(info confidential.ConfidentialEntry, domain string) error {
 	if len(info.Prefix) == 0 || len(info.Algorithm) == 0 || len(info.PublicKey) == 0 {
 		return nil
 	}
 
+	if err := confidential.CheckSignatureVersion(p.Version); err != nil {
+		return err
+	}
+
 	now := time.Now().UTC()
 	timestamp := time.Unix(0, p.Timestamp).UTC()
 	log.WithField("Timestamp", timestamp).Warning("VerifySignature")
 	delta := 24 * time.Hour
 
 	if p.PublicKey != info.PublicKey {
-		return errors.New("PublicKey not allowed")
+		return confidential.Mismatch(errors.New("PublicKey not allowed"))
 	}
 
 	if !timeInRange(now.Add(-delta), now.Add(delta), timestamp) {
-		return errors.New("timestamp not in time range")
+		return confidential.Mismatch(errors.New("timestamp not in time range"))
 	}
 
-	message := fmt.Sprintf("%v.%v", p.Name, p.Timestamp)
+	message, err := p.SignatureMessage(domain)
+	if err != nil {
+		return err
+	}
 	return confidential.VerifySignature(info, p.PublicKey, message, p.Algorithm, p.Signature)
 }
 
-func (p *DirectoryEntry) VerifySignature(info confidential.ConfidentialEntry) error {
+// RulePublicKey return the public key used to find confidential rule.
+// When entry is signed with delegated tokens, the rule key is the first token issuer.
+func (p *DirectoryEntry) RulePublicKey() string {
+	if len(p.Tokens) > 0 {
+		return p.Tokens[0].Issuer
+	}
+	return p.PublicKey
+}
+
+// SignatureMessage return the message signed by client for operation (add or remove)
+func (p *DirectoryEntry) SignatureMessage(domain, operation string) (string, error) {
+	return confidential.SignatureMessage(p.Version, operation, domain, p.Name, p.Entry, p.Mode, p.Timestamp, p.Nonce)
+}
+
+func (p *DirectoryEntry) VerifySignature(info confidential.ConfidentialEntry, domain, operation string) error {
 	if len(info.Prefix) == 0 || len(info.Algorithm) == 0 || len(info.PublicKey) == 0 {
 		return nil
 	}
 
+	if err := confidential.CheckSignatureVersion(p.Version); err != nil {
+		return err
+	}
+
+	if len(p.Tokens) > 0 {
+		// entry is signed by an ephemeral key, verify the whole delegation chain
+		delegate, err := confidential.VerifyDelegation(info, p.Tokens, p.Name, operation, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		info = delegate
+	}
+
 	if p.PublicKey != info.PublicKey {
-		return errors.New("PublicKey not allowed")
+		return confidential.Mismatch(errors.New("PublicKey not allowed"))
 	}
 
 	now := time.Now().UTC()
 	timestamp := time.Unix(0, p.Timestamp).UTC()
 	delta := 24 * time.Hour
 	if !timeInRange(now.Add(-delta), now.Add(delta), timestamp) {
-		return errors.New("timestamp not in time range")
+		return confidential.Mismatch(errors.New("timestamp not in time range"))
+	}
+	message, err := p.SignatureMessage(domain, operation)
+	if err != nil {
+		return err
 	}
-	message := fmt.Sprintf("%s.%d.%s", p.Name, p.Timestamp, p.Entry)
 	return confidential.VerifySignature(info, p.PublicKey, message, p.Algorithm, p.Signature)
 }
@@ -13,6 +13,9 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// seenMessages drop messages already applied, when relayed by several children or peers
+var seenMessages = p2p.NewSeenCache(p2p.DefaultSeenTTL)
+
 func StartIPCService(ctx context.Context, ready chan struct{}) {
 	if ipcServer := internal.IPCFromContext(ctx); ipcServer != nil {
 		ipcServer.Start(ctx, func(ctx context.Context, message ipc.Message) (ipc.Message, error) {
@@ -39,6 +42,14 @@ func ipcHandler(ctx context.Context, directory soroban.Directory, message ipc.Me
 
 		log.WithField("p2pMessage", fmt.Sprintf("%s: %s", p2pMessage.Context, string(p2pMessage.Payload))).Debug("Recieve message from IPC")
 
+		if seenMessages.Seen(p2pMessage.Hash()) {
+			log.WithField("ID", p2pMessage.ID).Trace("Skip duplicate message from IPC")
+			return ipc.Message{
+				Type:    message.Type,
+				Message: "success",
+			}, nil
+		}
+
 		var args DirectoryEntry
 
 		err = p2pMessage.ParsePayload(&args)
@@ -74,6 +85,39 @@ func ipcHandler(ctx context.Context, directory soroban.Directory, message ipc.Me
 			Type:    message.Type,
 			Message: "success",
 		}, nil
+	case ipc.MessageTypeStatus:
+		response, err := statusHandler(message)
+		if err != nil {
+			log.WithError(err).Error("failed to process status message.")
+			return ipc.Message{
+				Type:    message.Type,
+				Message: "error",
+			}, nil
+		}
+		return response, nil
+
+	case ipc.MessageTypeRuleset:
+		response, err := rulesetHandler(message)
+		if err != nil {
+			log.WithError(err).Error("failed to process ruleset message.")
+			return ipc.Message{
+				Type:    message.Type,
+				Message: "error",
+			}, nil
+		}
+		return response, nil
+
+	case ipc.MessageTypeSync:
+		response, err := syncHandler(directory, message)
+		if err != nil {
+			log.WithError(err).Error("failed to process sync message.")
+			return ipc.Message{
+				Type:    message.Type,
+				Message: "error",
+			}, nil
+		}
+		return response, nil
+
 	default:
 		// NOOP
 		return ipc.Message{
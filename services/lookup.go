@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	soroban "code.samourai.io/wallet/samourai-soroban"
+	"code.samourai.io/wallet/samourai-soroban/confidential"
+	"code.samourai.io/wallet/samourai-soroban/internal"
+	"code.samourai.io/wallet/samourai-soroban/ipc"
+	"code.samourai.io/wallet/samourai-soroban/p2p"
+)
+
+const (
+	// lookupInterval between federated lookups of a key, polling clients must not flood peers
+	lookupInterval = 10 * time.Second
+)
+
+var (
+	lookupThrottle = p2p.NewSeenCache(lookupInterval)
+)
+
+// federatedLookup ask p2p peers for entries of key, answers are cached in directory with their remaining TTL
+func federatedLookup(ctx context.Context, directory soroban.Directory, args *DirectoryEntries) ([]string, error) {
+	sync, ok := directory.(soroban.DirectorySync)
+	if !ok {
+		return nil, errors.New("directory sync not supported")
+	}
+	if p2P := internal.P2PFromContext(ctx); p2P != nil && !p2P.Replicated(args.Name) {
+		return nil, errors.New("key not replicated")
+	}
+	if lookupThrottle.Seen(args.Name) {
+		return nil, errors.New("lookup throttled")
+	}
+
+	request, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	items, err := lookupItems(ctx, args.Name, request)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) > 0 {
+		err = sync.Import(items)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return directory.List(args.Name)
+}
+
+// lookupItems lookup from local p2p node, or from an IPC child
+func lookupItems(ctx context.Context, name string, request []byte) ([]soroban.DirectoryItem, error) {
+	if p2P := internal.P2PFromContext(ctx); p2P != nil && p2P.Valid() {
+		return p2P.Lookup(ctx, name, request)
+	}
+
+	client := internal.IPCFromContext(ctx)
+	if client == nil || client.Mode() == "child" {
+		return nil, p2p.ErrNotStarted
+	}
+	message, err := client.Request(ipc.Message{
+		Type:    ipc.MessageTypeLookup,
+		Message: name,
+		Payload: string(request),
+	}, "down")
+	if err != nil {
+		return nil, err
+	}
+	if message.Message != "success" {
+		return nil, errors.New("child failed to lookup")
+	}
+
+	var result []soroban.DirectoryItem
+	err = json.Unmarshal([]byte(message.Payload), &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// LookupHandler process federated lookups requested by IPC server, in child mode
+func LookupHandler(ctx context.Context, message ipc.Message) (ipc.Message, error) {
+	p2P := internal.P2PFromContext(ctx)
+	if p2P == nil {
+		return ipc.Message{}, p2p.ErrNotStarted
+	}
+	items, err := p2P.Lookup(ctx, message.Message, []byte(message.Payload))
+	if err != nil {
+		return ipc.Message{}, err
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return ipc.Message{}, err
+	}
+	return ipc.Message{
+		Type:    message.Type,
+		Message: "success",
+		Payload: string(data),
+	}, nil
+}
+
+// lookupValidator check federated lookups received from peers with confidential rules,
+// the signed client request is verified for confidential keys.
+func lookupValidator(domain string) p2p.LookupValidator {
+	return func(name string, request []byte) error {
+		var args DirectoryEntries
+		if len(request) > 0 {
+			err := json.Unmarshal(request, &args)
+			if err != nil {
+				return err
+			}
+		}
+
+		// same rules as list
+		info := confidential.GetConfidentialInfo(name, args.PublicKey)
+		if !info.Confidential {
+			return nil
+		}
+		if args.Name != name {
+			return errors.New("request name mismatch")
+		}
+		return args.VerifySignature(info, domain)
+	}
+}
@@ -10,16 +10,35 @@ import (
 	soroban "code.samourai.io/wallet/samourai-soroban"
 	"code.samourai.io/wallet/samourai-soroban/internal"
 	"code.samourai.io/wallet/samourai-soroban/ipc"
+	"code.samourai.io/wallet/samourai-soroban/p2p"
 	log "github.com/sirupsen/logrus"
 )
 
-func StartP2PDirectory(ctx context.Context, p2pSeed, bootstrap string, hostname string, listenPort int, room string, ready chan struct{}) {
-	if len(bootstrap) == 0 {
-		log.Error("Invalid bootstrap")
-		return
+const (
+	// heartbeatName is the key of legacy heartbeats, sent as directory entries
+	heartbeatName = "p2p.heartbeat"
+
+	heartbeatDelay   = 30 * time.Second
+	recoveryMinDelay = time.Minute
+	recoveryMaxDelay = 15 * time.Minute
+)
+
+// hasBootstrap return true if any room has a bootstrap, or peer book knows peers
+func hasBootstrap(rooms []p2p.Room, peerBook *p2p.PeerBook) bool {
+	for _, room := range rooms {
+		if len(room.Bootstrap) > 0 {
+			return true
+		}
 	}
-	if len(room) == 0 {
-		log.Error("Invalid room")
+	return peerBook != nil && peerBook.Len() > 0
+}
+
+// StartP2PDirectory start p2p node and process messages.
+// When no heartbeat is received, p2p node recover with backoff. Process exits after exitTimeout if not zero.
+// Legacy heartbeats are sent as directory entries for older peers if legacyHeartbeat is set.
+func StartP2PDirectory(ctx context.Context, p2pSeed string, hostname string, listenPort int, rooms []p2p.Room, peerBook string, exitTimeout time.Duration, legacyHeartbeat bool, ready chan struct{}) {
+	if err := p2p.ValidateRooms(rooms); err != nil {
+		log.WithError(err).Error("Invalid room")
 		return
 	}
 
@@ -35,9 +54,39 @@ func StartP2PDirectory(ctx context.Context, p2pSeed, bootstrap string, hostname
 		return
 	}
 
+	// directory used for state sync with peers
+	switch sorobanMode {
+	case "child":
+		p2P.SetDirectorySync(&ipcDirectorySync{client: client})
+	default:
+		if directory, ok := internal.DirectoryFromContext(ctx).(soroban.DirectorySync); ok {
+			p2P.SetDirectorySync(directory)
+		}
+	}
+
+	// reconnect to known peers on restart
+	var book *p2p.PeerBook
+	if len(peerBook) > 0 {
+		var err error
+		book, err = p2p.LoadPeerBook(peerBook)
+		if err != nil {
+			log.WithError(err).WithField("Filename", peerBook).Error("Failed to load peer book")
+		} else {
+			p2P.SetPeerBook(book)
+		}
+	}
+	if !hasBootstrap(rooms, book) {
+		log.Error("Invalid bootstrap, no bootstrap or known peers")
+		return
+	}
+
+	// reject invalid messages before they are applied or relayed
+	p2P.SetValidator(messageValidator(internal.DomainFromContext(ctx), p2P))
+	p2P.SetLookupValidator(lookupValidator(internal.DomainFromContext(ctx)))
+
 	p2pReady := make(chan struct{})
 	go func() {
-		err := p2P.Start(ctx, p2pSeed, hostname, listenPort, bootstrap, room, p2pReady)
+		err := p2P.Start(ctx, p2pSeed, hostname, listenPort, rooms, p2pReady)
 		if err != nil {
 			log.WithError(err).Error("Failed to p2P.Start")
 		}
@@ -46,102 +95,198 @@ func StartP2PDirectory(ctx context.Context, p2pSeed, bootstrap string, hostname
 
 	<-p2pReady
 
+	// inbound messages are processed by workers, a slow IPC server does not stall gossip consumption
+	if queue := p2P.Queue(); queue != nil {
+		for i := 0; i < queue.Workers(); i++ {
+			go func() {
+				for {
+					select {
+					case message := <-queue.Messages():
+						processMessage(ctx, p2P, client, sorobanMode, message)
+						queue.Done()
+
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+		}
+	}
+
 	timeoutDelay := 15 * time.Minute // first timeout is longer at startup
-	lastHeartbeatTimestamp := time.Now().UTC()
+	startTimestamp := time.Now().UTC()
+	heartbeatCount := 0
+
+	recoveryDelay := recoveryMinDelay
+	var lastRecovery time.Time
+	recovering := false
+	recovered := make(chan error, 1)
+
+	ticker := time.NewTicker(heartbeatDelay)
+	defer ticker.Stop()
 	for {
 		select {
 		case message := <-p2P.OnMessage:
-			var args DirectoryEntry
+			processMessage(ctx, p2P, client, sorobanMode, message)
 
-			err := message.ParsePayload(&args)
+		case err := <-recovered:
+			recovering = false
 			if err != nil {
-				log.WithError(err).Error("Failed to ParsePayload")
-				continue
+				log.WithError(err).Warning("p2p - recovery failed")
+				recoveryDelay *= 2
+				if recoveryDelay > recoveryMaxDelay {
+					recoveryDelay = recoveryMaxDelay
+				}
 			}
 
-			if args.Name == "p2p.heartbeat" {
+		case <-ticker.C:
+			lastHeartbeatTimestamp := p2P.LastHeartbeat()
+			if lastHeartbeatTimestamp.IsZero() {
+				lastHeartbeatTimestamp = startTimestamp
+			} else {
 				timeoutDelay = 3 * time.Minute // reduce timeout delay after first heartbeat received
-				lastHeartbeatTimestamp = time.Now()
-
-				log.Trace("p2p - heartbeat received")
-				continue
+			}
+			if time.Since(lastHeartbeatTimestamp) <= timeoutDelay {
+				recoveryDelay = recoveryMinDelay
 			}
 
-			log.WithField("message", fmt.Sprintf("%s: %s", message.Context, string(message.Payload))).Debug("Recieved message from p2p")
-
-			switch sorobanMode {
-			case "child":
-				// foward P2P message to IPC server
-				data, err := json.Marshal(message)
-				if err != nil {
-					log.WithError(err).Error("failed to marshal p2p message.")
-					continue
-				}
-				message, err := client.Request(ipc.Message{
-					Type:    ipc.MessageTypeSoroban,
-					Payload: string(data),
-				}, "up")
-				if err != nil {
-					log.WithError(err).Error("failed send ipc request.")
-					continue
-				}
-				if message.Message != "success" {
-					log.WithField("Message", message.Message).Warning("IPC Message failed")
-				}
-				log.WithField("Message", message.Message).Debug("IPC Message sent")
-				continue
-
-			default:
-				// Default P2P mode, with directory available
-				directory := internal.DirectoryFromContext(ctx)
-				if directory == nil {
-					log.Error("Directory not found")
-					continue
-				}
-
-				switch message.Context {
-				case "Directory.Add":
-					err = addToDirectory(directory, &args)
-
-				case "Directory.Remove":
-					err = removeFromDirectory(directory, &args)
-				}
-				if err != nil {
-					log.WithError(err).Error("failed to process message.")
-					continue
+			if time.Since(lastHeartbeatTimestamp) > timeoutDelay {
+				// exit is a last resort, only if enabled
+				if exitTimeout > 0 && time.Since(lastHeartbeatTimestamp) > exitTimeout {
+					log.Warning("No message received from too long, exiting...")
+					soroban.Shutdown(ctx)
+					os.Exit(0)
 				}
 
-				if ipcClient := internal.IPCFromContext(ctx); ipcClient != nil {
-					ipcClient.Request(ipc.Message{
-						Type:    ipc.MessageTypeSoroban,
-						Payload: string(message.Payload),
-					}, "up")
+				p2P.SetDegraded()
+				if !recovering && time.Since(lastRecovery) > recoveryDelay {
+					log.WithField("Delay", recoveryDelay).Warning("No message received from too long, recovering...")
+					recovering = true
+					lastRecovery = time.Now()
+					go func() {
+						recovered <- p2P.Recover(ctx)
+					}()
 				}
+			}
 
-				continue
+			if legacyHeartbeat {
+				publishLegacyHeartbeat(ctx, p2P)
 			}
 
-		case <-time.After(30 * time.Second):
-			if time.Since(lastHeartbeatTimestamp) > timeoutDelay {
-				log.Warning("No message received from too long, exiting...")
-				soroban.Shutdown(ctx)
-				os.Exit(0)
+			// report p2p status to IPC server
+			if sorobanMode == "child" {
+				reportStatus(client, p2P)
 			}
 
-			err := p2P.PublishJson(ctx, "Directory.Add", DirectoryEntry{
-				Name:  "p2p.heartbeat",
-				Entry: fmt.Sprintf("%d", time.Now().Unix()),
-				Mode:  "short",
-			})
-			if err != nil {
-				// non fatal error
-				log.Warningf("p2p - Failed to PublishJson. %s\n", err)
-				continue
+			// republish admin ruleset every 5 minutes
+			heartbeatCount++
+			if heartbeatCount%10 == 0 {
+				publishRuleset(ctx, p2P)
 			}
-			log.Trace("p2p - heartbeat sent")
 
 		case <-ctx.Done():
 			return
 		}
 	}
 }
+
+// publishLegacyHeartbeat send heartbeat entry in all rooms, for older peers
+func publishLegacyHeartbeat(ctx context.Context, p2P *p2p.P2P) {
+	for _, room := range p2P.Rooms() {
+		message, err := p2p.NewMessage("Directory.Add", DirectoryEntry{
+			Name:  heartbeatName,
+			Entry: fmt.Sprintf("%d", time.Now().Unix()),
+			Mode:  "short",
+		})
+		if err == nil {
+			err = p2P.PublishMessage(ctx, room, message)
+		}
+		if err != nil {
+			// non fatal error
+			log.Warningf("p2p - Failed to PublishJson. %s\n", err)
+			continue
+		}
+		log.WithField("Room", room).Trace("p2p - legacy heartbeat sent")
+	}
+}
+
+// processMessage apply message received from p2p, or forward it to IPC server in child mode
+func processMessage(ctx context.Context, p2P *p2p.P2P, client *ipc.IPCService, sorobanMode string, message p2p.Message) {
+	if message.Context == ContextConfidentialRuleset {
+		processRuleset(client, sorobanMode, message)
+		return
+	}
+
+	var args DirectoryEntry
+
+	err := message.ParsePayload(&args)
+	if err != nil {
+		log.WithError(err).Error("Failed to ParsePayload")
+		return
+	}
+
+	// legacy heartbeats are never stored, only sending them is optional.
+	// Origin is chosen by sender, heartbeat is recorded for the authenticated pubsub sender.
+	if args.Name == heartbeatName {
+		p2P.HeartbeatReceived(message.From.String())
+
+		log.Trace("p2p - legacy heartbeat received")
+		return
+	}
+
+	log.WithField("message", fmt.Sprintf("%s: %s", message.Context, string(message.Payload))).Debug("Recieved message from p2p")
+
+	switch sorobanMode {
+	case "child":
+		// foward P2P message to IPC server
+		forwarded, err := message.Forward()
+		if err != nil {
+			log.WithError(err).WithField("ID", message.ID).Debug("Skip message")
+			return
+		}
+		data, err := json.Marshal(forwarded)
+		if err != nil {
+			log.WithError(err).Error("failed to marshal p2p message.")
+			return
+		}
+		message, err := client.Request(ipc.Message{
+			Type:    ipc.MessageTypeSoroban,
+			Payload: string(data),
+		}, "up")
+		if err != nil {
+			log.WithError(err).Error("failed send ipc request.")
+			return
+		}
+		if message.Message != "success" {
+			log.WithField("Message", message.Message).Warning("IPC Message failed")
+		}
+		log.WithField("Message", message.Message).Debug("IPC Message sent")
+
+	default:
+		// Default P2P mode, with directory available
+		directory := internal.DirectoryFromContext(ctx)
+		if directory == nil {
+			log.Error("Directory not found")
+			return
+		}
+
+		switch message.Context {
+		case "Directory.Add":
+			err = addToDirectory(directory, &args)
+
+		case "Directory.Remove":
+			err = removeFromDirectory(directory, &args)
+		}
+		if err != nil {
+			log.WithError(err).Error("failed to process message.")
+			return
+		}
+
+		if ipcClient := internal.IPCFromContext(ctx); ipcClient != nil {
+			ipcClient.Request(ipc.Message{
+				Type:    ipc.MessageTypeSoroban,
+				Payload: string(message.Payload),
+			}, "up")
+		}
+	}
+}
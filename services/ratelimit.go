@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"code.samourai.io/wallet/samourai-soroban/confidential"
+	"code.samourai.io/wallet/samourai-soroban/internal"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	ErrRateLimited = errors.New("rate limit exceeded")
+)
+
+// checkRateLimit consume rate limit tokens for directory name, confidential rule and signing public key.
+// Unsigned writes are first limited per name, a client flooding a name does not exhaust the rule limit of other names.
+// Rule limit is shared by all names matching rule prefix, names are chosen by clients.
+// Public key limit only applies when signature was verified, since anonymous keys are not authenticated.
+// Must only be called for json-rpc requests, replicated writes were limited by the node receiving the request.
+// Json-rpc is only served by the main process, IPC children only relay p2p messages and share no limiter state.
+func checkRateLimit(ctx context.Context, args *DirectoryEntry, verified bool) error {
+	limiter := internal.RateLimiterFromContext(ctx)
+	if limiter == nil || args == nil {
+		return nil
+	}
+
+	info := confidential.GetConfidentialInfo(args.Name, args.RulePublicKey())
+	limits := info.RateLimit
+
+	if len(info.Prefix) == 0 {
+		return nil
+	}
+
+	if !verified && !limiter.Allow("n:"+info.Prefix+":"+args.Name, limits.Name.Rate, limits.Name.Burst) {
+		log.WithField("Prefix", info.Prefix).WithField("Name", args.Name).Warning("Rate limit exceeded for name")
+		return ErrRateLimited
+	}
+
+	if !limiter.Allow("r:"+info.Prefix, limits.Key.Rate, limits.Key.Burst) {
+		log.WithField("Prefix", info.Prefix).WithField("Name", args.Name).Warning("Rate limit exceeded for key")
+		return ErrRateLimited
+	}
+
+	if verified && len(args.PublicKey) > 0 {
+		if !limiter.Allow("p:"+info.Prefix+":"+args.PublicKey, limits.PublicKey.Rate, limits.PublicKey.Burst) {
+			log.WithField("PublicKey", args.PublicKey).Warning("Rate limit exceeded for public key")
+			return ErrRateLimited
+		}
+	}
+	return nil
+}
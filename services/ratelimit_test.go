@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"code.samourai.io/wallet/samourai-soroban/confidential"
+	"code.samourai.io/wallet/samourai-soroban/internal"
+	"code.samourai.io/wallet/samourai-soroban/internal/ratelimit"
+)
+
+func TestCheckRateLimit(t *testing.T) {
+	defer confidential.SetConfig(confidential.Config())
+	confidential.SetConfig(confidential.SorobanConfig{
+		Confidential: []confidential.ConfidentialEntry{
+			{
+				Prefix: "queue.*",
+				RateLimit: confidential.RateLimitEntry{
+					Key:  confidential.RateLimit{Rate: 0.001, Burst: 5},
+					Name: confidential.RateLimit{Rate: 0.001, Burst: 2},
+				},
+			},
+		},
+	})
+	ctx := context.WithValue(context.Background(), internal.SorobanRateLimitKey, ratelimit.New())
+
+	// flooding a name does not exhaust the rule limit
+	flooded := &DirectoryEntry{Name: "queue.flooded", Entry: "a"}
+	for i := 0; i < 2; i++ {
+		if err := checkRateLimit(ctx, flooded, false); err != nil {
+			t.Fatalf("checkRateLimit() error = %v", err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		if err := checkRateLimit(ctx, flooded, false); err != ErrRateLimited {
+			t.Fatalf("checkRateLimit() error = %v, want %v", err, ErrRateLimited)
+		}
+	}
+
+	other := &DirectoryEntry{Name: "queue.other", Entry: "b"}
+	if err := checkRateLimit(ctx, other, false); err != nil {
+		t.Errorf("checkRateLimit() other name error = %v", err)
+	}
+
+	// rule limit is shared by names matching prefix
+	for _, name := range []string{"queue.1", "queue.2"} {
+		if err := checkRateLimit(ctx, &DirectoryEntry{Name: name, Entry: "c"}, false); err != nil {
+			t.Fatalf("checkRateLimit(%s) error = %v", name, err)
+		}
+	}
+	if err := checkRateLimit(ctx, &DirectoryEntry{Name: "queue.3", Entry: "c"}, false); err != ErrRateLimited {
+		t.Errorf("checkRateLimit() rule error = %v, want %v", err, ErrRateLimited)
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"code.samourai.io/wallet/samourai-soroban/internal"
+	"code.samourai.io/wallet/samourai-soroban/ipc"
+	"code.samourai.io/wallet/samourai-soroban/p2p"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// childStatusTTL before status of a child is considered stale
+	childStatusTTL = 2 * time.Minute
+)
+
+// p2pReport is sent by IPC children to IPC server
+type p2pReport struct {
+	Status p2p.Status
+	Peers  p2p.Peers
+}
+
+// PeersRequest for json-rpc request
+type PeersRequest struct{}
+
+// PeersResponse for json-rpc response
+type PeersResponse struct {
+	Nodes []p2p.Peers
+}
+
+var (
+	childReports      = make(map[string]p2pReport)
+	childStatusLocker sync.Mutex
+)
+
+// reports return non stale reports of IPC children, sorted by peer ID
+func reports() []p2pReport {
+	childStatusLocker.Lock()
+	defer childStatusLocker.Unlock()
+
+	result := make([]p2pReport, 0, len(childReports))
+	for key, report := range childReports {
+		if time.Since(report.Status.UpdatedAt) > childStatusTTL {
+			delete(childReports, key)
+			continue
+		}
+		result = append(result, report)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Status.PeerID < result[j].Status.PeerID
+	})
+	return result
+}
+
+// P2PStatus return status of local p2p node, or status reported by IPC children
+func P2PStatus(ctx context.Context) []p2p.Status {
+	if p2P := internal.P2PFromContext(ctx); p2P != nil && p2P.Valid() {
+		return []p2p.Status{p2P.Status()}
+	}
+
+	result := make([]p2p.Status, 0)
+	for _, report := range reports() {
+		result = append(result, report.Status)
+	}
+	return result
+}
+
+// P2PPeers return peers of local p2p node, or peers reported by IPC children
+func P2PPeers(ctx context.Context) []p2p.Peers {
+	if p2P := internal.P2PFromContext(ctx); p2P != nil && p2P.Valid() {
+		return []p2p.Peers{p2P.Peers()}
+	}
+
+	result := make([]p2p.Peers, 0)
+	for _, report := range reports() {
+		result = append(result, report.Peers)
+	}
+	return result
+}
+
+// Peers return connected peers and topology of p2p nodes
+func (t *P2P) Peers(r *http.Request, args *PeersRequest, result *PeersResponse) error {
+	*result = PeersResponse{
+		Nodes: P2PPeers(r.Context()),
+	}
+	return nil
+}
+
+// reportStatus send p2p status and peers to IPC server
+func reportStatus(client *ipc.IPCService, p2P *p2p.P2P) {
+	data, err := json.Marshal(p2pReport{
+		Status: p2P.Status(),
+		Peers:  p2P.Peers(),
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to marshal p2p status")
+		return
+	}
+	_, err = client.Request(ipc.Message{
+		Type:    ipc.MessageTypeStatus,
+		Payload: string(data),
+	}, "up")
+	if err != nil {
+		log.WithError(err).Warning("Failed to report p2p status")
+	}
+}
+
+// statusHandler keep p2p status and peers reported by IPC children
+func statusHandler(message ipc.Message) (ipc.Message, error) {
+	var report p2pReport
+	err := json.Unmarshal([]byte(message.Payload), &report)
+	if err != nil {
+		return ipc.Message{}, err
+	}
+	report.Status.UpdatedAt = time.Now().UTC()
+
+	childStatusLocker.Lock()
+	childReports[report.Status.PeerID] = report
+	childStatusLocker.Unlock()
+
+	return ipc.Message{
+		Type:    message.Type,
+		Message: "success",
+	}, nil
+}
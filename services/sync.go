@@ -0,0 +1,159 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+
+	soroban "code.samourai.io/wallet/samourai-soroban"
+	"code.samourai.io/wallet/samourai-soroban/ipc"
+)
+
+const (
+	syncExport = "export"
+	syncImport = "import"
+	syncLookup = "lookup"
+)
+
+// ipcDirectorySync forward state sync to the IPC server directory, used in child mode
+type ipcDirectorySync struct {
+	client *ipc.IPCService
+}
+
+// syncExportRequest select a page of exported items
+type syncExportRequest struct {
+	After string `json:",omitempty"`
+	Limit int    `json:",omitempty"`
+}
+
+func (p *ipcDirectorySync) Export(after string, limit int) ([]soroban.DirectoryItem, error) {
+	data, err := json.Marshal(syncExportRequest{
+		After: after,
+		Limit: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	message, err := p.client.Request(ipc.Message{
+		Type:    ipc.MessageTypeSync,
+		Message: syncExport,
+		Payload: string(data),
+	}, "up")
+	if err != nil {
+		return nil, err
+	}
+	if message.Message != "success" {
+		return nil, errors.New("failed to export directory")
+	}
+
+	var result []soroban.DirectoryItem
+	err = json.Unmarshal([]byte(message.Payload), &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (p *ipcDirectorySync) Import(items []soroban.DirectoryItem) error {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	message, err := p.client.Request(ipc.Message{
+		Type:    ipc.MessageTypeSync,
+		Message: syncImport,
+		Payload: string(data),
+	}, "up")
+	if err != nil {
+		return err
+	}
+	if message.Message != "success" {
+		return errors.New("failed to import directory")
+	}
+	return nil
+}
+
+func (p *ipcDirectorySync) Lookup(name string) ([]soroban.DirectoryItem, error) {
+	message, err := p.client.Request(ipc.Message{
+		Type:    ipc.MessageTypeSync,
+		Message: syncLookup,
+		Payload: name,
+	}, "up")
+	if err != nil {
+		return nil, err
+	}
+	if message.Message != "success" {
+		return nil, errors.New("failed to lookup directory")
+	}
+
+	var result []soroban.DirectoryItem
+	err = json.Unmarshal([]byte(message.Payload), &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// syncHandler process state sync requests from IPC children
+func syncHandler(directory soroban.Directory, message ipc.Message) (ipc.Message, error) {
+	sync, ok := directory.(soroban.DirectorySync)
+	if !ok {
+		return ipc.Message{}, errors.New("directory sync not supported")
+	}
+
+	switch message.Message {
+	case syncExport:
+		var request syncExportRequest
+		if len(message.Payload) > 0 {
+			err := json.Unmarshal([]byte(message.Payload), &request)
+			if err != nil {
+				return ipc.Message{}, err
+			}
+		}
+		items, err := sync.Export(request.After, request.Limit)
+		if err != nil {
+			return ipc.Message{}, err
+		}
+		data, err := json.Marshal(items)
+		if err != nil {
+			return ipc.Message{}, err
+		}
+		return ipc.Message{
+			Type:    message.Type,
+			Message: "success",
+			Payload: string(data),
+		}, nil
+
+	case syncLookup:
+		items, err := sync.Lookup(message.Payload)
+		if err != nil {
+			return ipc.Message{}, err
+		}
+		data, err := json.Marshal(items)
+		if err != nil {
+			return ipc.Message{}, err
+		}
+		return ipc.Message{
+			Type:    message.Type,
+			Message: "success",
+			Payload: string(data),
+		}, nil
+
+	case syncImport:
+		var items []soroban.DirectoryItem
+		err := json.Unmarshal([]byte(message.Payload), &items)
+		if err != nil {
+			return ipc.Message{}, err
+		}
+		err = sync.Import(items)
+		if err != nil {
+			return ipc.Message{}, err
+		}
+		return ipc.Message{
+			Type:    message.Type,
+			Message: "success",
+		}, nil
+
+	default:
+		return ipc.Message{}, errors.New("unknown sync request")
+	}
+}
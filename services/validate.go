@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"code.samourai.io/wallet/samourai-soroban/confidential"
+	"code.samourai.io/wallet/samourai-soroban/p2p"
+)
+
+// messageValidator return p2p validator checking confidential rules and rooms
+// before messages are applied and relayed to other peers.
+// Messages not matching local config are ignored, only bad signatures or encodings are rejected.
+func messageValidator(domain string, p2P *p2p.P2P) p2p.Validator {
+	validator := validateMessage(domain, p2P)
+	return func(room string, message p2p.Message) error {
+		return ignoreMismatch(validator(room, message))
+	}
+}
+
+// ignoreMismatch wrap errors of local config mismatches in p2p.ErrIgnore,
+// config, keys or clocks of honest peers can differ during rollouts
+func ignoreMismatch(err error) error {
+	if errors.Is(err, confidential.ErrMismatch) {
+		return fmt.Errorf("%w: %v", p2p.ErrIgnore, err)
+	}
+	return err
+}
+
+func validateMessage(domain string, p2P *p2p.P2P) p2p.Validator {
+	return func(room string, message p2p.Message) error {
+		switch message.Context {
+		case "Directory.Add":
+			return validateDirectoryEntry(message, p2P, room, domain, confidential.OperationAdd)
+
+		case "Directory.Remove":
+			return validateDirectoryEntry(message, p2P, room, domain, confidential.OperationRemove)
+
+		case ContextConfidentialRuleset:
+			if !confidential.AdminEnabled() {
+				// ruleset can't be verified without admin key, relay it
+				return nil
+			}
+			var ruleset confidential.Ruleset
+			err := message.ParsePayload(&ruleset)
+			if err != nil {
+				return err
+			}
+			if algorithm, publicKey := confidential.Admin(); ruleset.Algorithm != algorithm || ruleset.PublicKey != publicKey {
+				return fmt.Errorf("%w: ruleset of another admin key", p2p.ErrIgnore)
+			}
+			return confidential.VerifyRuleset(ruleset)
+
+		default:
+			// contexts of newer peers
+			return fmt.Errorf("%w: unknown p2p message context", p2p.ErrIgnore)
+		}
+	}
+}
+
+func validateDirectoryEntry(message p2p.Message, p2P *p2p.P2P, room, domain, operation string) error {
+	var args DirectoryEntry
+	err := message.ParsePayload(&args)
+	if err != nil {
+		return err
+	}
+	if len(args.Name) == 0 || len(args.Entry) == 0 {
+		return errors.New("invalid directory entry")
+	}
+	// heartbeats are sent in all rooms, entries only in their room.
+	// Keys local or replicated in another room by local policies are ignored.
+	if args.Name != heartbeatName {
+		if err := p2P.CheckRoom(room, args.Name); err != nil {
+			return err
+		}
+	}
+
+	return verifyDirectoryEntry(&args, domain, operation)
+}
+
+// verifyDirectoryEntry check signature of writes on readonly keys, same as RPC requests
+func verifyDirectoryEntry(args *DirectoryEntry, domain, operation string) error {
+	info := confidential.GetConfidentialInfo(args.Name, args.RulePublicKey())
+	if !info.ReadOnly {
+		return nil
+	}
+	return args.VerifySignature(info, domain, operation)
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"code.samourai.io/wallet/samourai-soroban/confidential"
+	"code.samourai.io/wallet/samourai-soroban/p2p"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+const (
+	testRuleKey       = "L3xJb1qTaa5DUpmMgb2yKMy9n1nxCYAPuMhA34EeZ3Ua2Xr9wyDF"
+	testRulePublicKey = "024d1d2028d6a503c5d688425eddcb9a348696d606fb6d521b8a336de760d51e8e"
+	testDomain        = "test"
+)
+
+func newTestPublicKey(t *testing.T) string {
+	privKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(privKey.PubKey().SerializeCompressed())
+}
+
+func signTestEntry(t *testing.T, entry DirectoryEntry) *DirectoryEntry {
+	message, err := entry.SignatureMessage(testDomain, confidential.OperationAdd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alg, err := confidential.GetAlgorithm(confidential.AlgorithmEcdsa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Signature, err = alg.Sign(testRuleKey, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &entry
+}
+
+func setTestConfig(rulePublicKey string, allowV1 bool) {
+	confidential.SetConfig(confidential.SorobanConfig{
+		AllowSignatureV1: &allowV1,
+		Confidential: []confidential.ConfidentialEntry{
+			{Prefix: "signed.*", Algorithm: confidential.AlgorithmEcdsa, PublicKey: rulePublicKey, ReadOnly: true},
+		},
+	})
+}
+
+func TestValidateDirectoryEntry(t *testing.T) {
+	defer confidential.SetConfig(confidential.Config())
+
+	entry := DirectoryEntry{
+		Name:      "signed.key",
+		Entry:     "value",
+		Mode:      "short",
+		PublicKey: testRulePublicKey,
+		Algorithm: confidential.AlgorithmEcdsa,
+		Timestamp: time.Now().UnixNano(),
+		Version:   confidential.SignatureV2,
+		Nonce:     "nonce",
+	}
+	valid := signTestEntry(t, entry)
+	tampered := *valid
+	tampered.Entry = "other"
+	v1 := entry
+	v1.Version = confidential.SignatureV1
+	skewed := entry
+	skewed.Timestamp = time.Now().Add(-48 * time.Hour).UnixNano()
+
+	tests := []struct {
+		name       string
+		entry      *DirectoryEntry
+		ruleKey    string
+		allowV1    bool
+		wantErr    bool
+		wantIgnore bool
+	}{
+		{"valid", valid, testRulePublicKey, true, false, false},
+		{"bad signature", &tampered, testRulePublicKey, true, true, false},
+		{"v1 not allowed", signTestEntry(t, v1), testRulePublicKey, false, true, true},
+		{"rule key rotated", valid, newTestPublicKey(t), true, true, true},
+		{"clock skew", signTestEntry(t, skewed), testRulePublicKey, true, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestConfig(tt.ruleKey, tt.allowV1)
+
+			err := ignoreMismatch(verifyDirectoryEntry(tt.entry, testDomain, confidential.OperationAdd))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verifyDirectoryEntry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, p2p.ErrIgnore) != tt.wantIgnore {
+				t.Errorf("verifyDirectoryEntry() error = %v, wantIgnore %v", err, tt.wantIgnore)
+			}
+		})
+	}
+}
+
+func TestMessageValidator(t *testing.T) {
+	defer confidential.SetConfig(confidential.Config())
+	setTestConfig(testRulePublicKey, true)
+	if err := confidential.SetAdmin(confidential.AlgorithmEcdsa, testRulePublicKey, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	ruleset, err := confidential.SignRuleset(confidential.AlgorithmEcdsa, testRuleKey, 1, []byte("confidential: []\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := ruleset
+	tampered.Version = 2
+	// fields of newer versions are unknown, config is parsed strictly
+	newer := ruleset
+	newer.Config = "confidential: []\nnewfield: true\n"
+	alg, err := confidential.GetAlgorithm(confidential.AlgorithmEcdsa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newer.Signature, err = alg.Sign(testRuleKey, newer.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newMessage := func(context string, payload interface{}) p2p.Message {
+		message, err := p2p.NewMessage(context, payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return message
+	}
+	undecodable := newMessage("Directory.Add", "entry")
+
+	tests := []struct {
+		name       string
+		message    p2p.Message
+		wantErr    bool
+		wantIgnore bool
+	}{
+		{"ruleset", newMessage(ContextConfidentialRuleset, ruleset), false, false},
+		{"ruleset bad signature", newMessage(ContextConfidentialRuleset, tampered), true, false},
+		{"ruleset newer config", newMessage(ContextConfidentialRuleset, newer), true, true},
+		{"undecodable entry", undecodable, true, false},
+		{"unknown context", newMessage("Directory.Unknown", "payload"), true, true},
+	}
+	validator := messageValidator(testDomain, &p2p.P2P{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator("default", tt.message)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("messageValidator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, p2p.ErrIgnore) != tt.wantIgnore {
+				t.Errorf("messageValidator() error = %v, wantIgnore %v", err, tt.wantIgnore)
+			}
+		})
+	}
+}
@@ -31,6 +31,7 @@ type StatusInfo struct {
 	Replication  NameValue `json:"replication,omitempty"`
 	Server       NameValue `json:"server,omitempty"`
 	Stats        NameValue `json:"stats,omitempty"`
+	P2P          NameValue `json:"p2p,omitempty"`
 	Raw          string    `json:"_raw,omitempty"`
 }
 
@@ -54,3 +55,27 @@ type Directory interface {
 	// Remove value from key.
 	Remove(key, value string) error
 }
+
+// DirectoryItem is a raw directory value with its expiration date.
+// Key is the internal (hashed) key, items are exchanged between peers with the same domain.
+// Name is the directory name, used to filter items by room.
+type DirectoryItem struct {
+	Key      string
+	Name     string `json:",omitempty"`
+	Value    string
+	ExpireOn time.Time
+}
+
+// DirectorySync interface for directories supporting state sync between peers
+type DirectorySync interface {
+	// Export return non-expired items of at most limit keys greater than after, ordered by key and value.
+	// Values of a key are never split between pages, limit lower or equals to zero return all keys.
+	Export(after string, limit int) ([]DirectoryItem, error)
+
+	// Import merge items in directory, keeping latest expiration date.
+	// Items without name, or removed recently, are ignored. Expiration dates are capped to the longest TTL.
+	Import(items []DirectoryItem) error
+
+	// Lookup return non-expired items of a directory name, ordered by value.
+	Lookup(name string) ([]DirectoryItem, error)
+}